@@ -0,0 +1,304 @@
+// Package analytics provides process mining statistics
+// across many instances of a workflow.
+package analytics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/dracory/swf"
+	"github.com/samber/lo"
+)
+
+// Options configures the analysis
+type Options struct {
+	// Steps is the order of the steps in the report.
+	// Steps not listed are appended in order of first appearance.
+	Steps []string
+
+	// StaleAfter is the time after which an unfinished instance, which has not
+	// moved from its current step, is considered abandoned at that step.
+	// Zero disables abandonment detection.
+	StaleAfter time.Duration
+
+	// Now is the time of the analysis, defaults to the current time
+	Now time.Time
+}
+
+// StepStats contains the statistics of a single step across instances
+type StepStats struct {
+	// Step is the name of the step
+	Step string
+
+	// Instances is the number of instances which entered the step
+	Instances int
+
+	// Visits is the number of times the step was entered, including rework
+	Visits int
+
+	// Throughput is the number of instances which completed the step
+	Throughput int
+
+	// AverageDwell is the average time spent on the step
+	AverageDwell time.Duration
+
+	// MedianDwell is the median time spent on the step
+	MedianDwell time.Duration
+
+	// ReworkCount is the number of instances which entered the step more than once
+	ReworkCount int
+
+	// ReworkRate is ReworkCount divided by Instances
+	ReworkRate float64
+
+	// InFlight is the number of unfinished instances currently at the step
+	InFlight int
+
+	// Abandoned is the number of unfinished instances stale at the step
+	Abandoned int
+
+	// AbandonmentRate is Abandoned divided by Instances
+	AbandonmentRate float64
+}
+
+// Report contains the statistics across all analyzed instances
+type Report struct {
+	// Instances is the number of analyzed instances
+	Instances int
+
+	// Finished is the number of instances with the current step completed
+	Finished int
+
+	// Bottleneck is the step with the highest median dwell time
+	Bottleneck string
+
+	// Steps contains the statistics per step
+	Steps []*StepStats
+}
+
+// Analyze calculates the per step statistics across the given workflow states
+//
+// Business logic:
+// 1. Count the instances and visits per step from the history
+// 2. Steps entered more than once by an instance count as rework
+// 3. Steps with both started and completed times contribute their dwell time
+// 4. Unfinished instances count as in flight at their current step,
+// and as abandoned when they have not moved for longer than StaleAfter
+func Analyze(states []*swf.WorkflowState, options Options) *Report {
+	now := options.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	report := &Report{Steps: []*StepStats{}}
+	stats := map[string]*StepStats{}
+	dwells := map[string][]time.Duration{}
+
+	getStats := func(name string) *StepStats {
+		if stats[name] == nil {
+			stats[name] = &StepStats{Step: name}
+		}
+		return stats[name]
+	}
+
+	for _, name := range options.Steps {
+		getStats(name)
+	}
+
+	order := append([]string{}, options.Steps...)
+
+	for _, state := range states {
+		if state == nil {
+			continue
+		}
+
+		report.Instances++
+
+		visits := lo.CountValues(state.History)
+		for _, name := range lo.Uniq(state.History) {
+			if !lo.Contains(order, name) {
+				order = append(order, name)
+			}
+
+			step := getStats(name)
+			step.Instances++
+			step.Visits += visits[name]
+			if visits[name] > 1 {
+				step.ReworkCount++
+			}
+		}
+
+		for name, details := range state.StepDetails {
+			if details == nil || details.Completed == "" {
+				continue
+			}
+
+			getStats(name).Throughput++
+
+			if duration, ok := details.Duration(); ok {
+				dwells[name] = append(dwells[name], duration)
+			}
+		}
+
+		if state.CurrentStepName == "" {
+			continue
+		}
+
+		current := state.StepDetails[state.CurrentStepName]
+		if current != nil && current.Completed != "" {
+			report.Finished++
+			continue
+		}
+
+		step := getStats(state.CurrentStepName)
+		step.InFlight++
+
+		if options.StaleAfter > 0 && current != nil {
+			if started, ok := current.StartedAt(); ok && now.Sub(started) > options.StaleAfter {
+				step.Abandoned++
+			}
+		}
+
+		if !lo.Contains(order, state.CurrentStepName) {
+			order = append(order, state.CurrentStepName)
+		}
+	}
+
+	// Steps only known from their details
+	detailNames := lo.Keys(stats)
+	sort.Strings(detailNames)
+	for _, name := range detailNames {
+		if !lo.Contains(order, name) {
+			order = append(order, name)
+		}
+	}
+
+	var bottleneckDwell time.Duration
+	for _, name := range order {
+		step := stats[name]
+
+		values := dwells[name]
+		if len(values) > 0 {
+			sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+			total := lo.Sum(values)
+			step.AverageDwell = total / time.Duration(len(values))
+			step.MedianDwell = swf.DurationPercentile(values, 50)
+		}
+
+		if step.Instances > 0 {
+			step.ReworkRate = float64(step.ReworkCount) / float64(step.Instances)
+			step.AbandonmentRate = float64(step.Abandoned) / float64(step.Instances)
+		}
+
+		if step.MedianDwell > bottleneckDwell {
+			bottleneckDwell = step.MedianDwell
+			report.Bottleneck = name
+		}
+
+		report.Steps = append(report.Steps, step)
+	}
+
+	return report
+}
+
+// stepStatsJSON is the JSON representation of StepStats, with durations in seconds
+type stepStatsJSON struct {
+	Step                string  `json:"step"`
+	Instances           int     `json:"instances"`
+	Visits              int     `json:"visits"`
+	Throughput          int     `json:"throughput"`
+	AverageDwellSeconds float64 `json:"average_dwell_seconds"`
+	MedianDwellSeconds  float64 `json:"median_dwell_seconds"`
+	ReworkCount         int     `json:"rework_count"`
+	ReworkRate          float64 `json:"rework_rate"`
+	InFlight            int     `json:"in_flight"`
+	Abandoned           int     `json:"abandoned"`
+	AbandonmentRate     float64 `json:"abandonment_rate"`
+}
+
+// MarshalJSON encodes the step statistics, with durations in seconds
+func (s *StepStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(stepStatsJSON{
+		Step:                s.Step,
+		Instances:           s.Instances,
+		Visits:              s.Visits,
+		Throughput:          s.Throughput,
+		AverageDwellSeconds: s.AverageDwell.Seconds(),
+		MedianDwellSeconds:  s.MedianDwell.Seconds(),
+		ReworkCount:         s.ReworkCount,
+		ReworkRate:          s.ReworkRate,
+		InFlight:            s.InFlight,
+		Abandoned:           s.Abandoned,
+		AbandonmentRate:     s.AbandonmentRate,
+	})
+}
+
+// ToJSON returns the report as JSON
+func (r *Report) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(struct {
+		Instances  int          `json:"instances"`
+		Finished   int          `json:"finished"`
+		Bottleneck string       `json:"bottleneck"`
+		Steps      []*StepStats `json:"steps"`
+	}{
+		Instances:  r.Instances,
+		Finished:   r.Finished,
+		Bottleneck: r.Bottleneck,
+		Steps:      r.Steps,
+	}, "", "  ")
+}
+
+// csvHeader is the header row of the CSV output
+var csvHeader = []string{
+	"step",
+	"instances",
+	"visits",
+	"throughput",
+	"average_dwell_seconds",
+	"median_dwell_seconds",
+	"rework_count",
+	"rework_rate",
+	"in_flight",
+	"abandoned",
+	"abandonment_rate",
+}
+
+// WriteCSV writes the per step statistics as CSV, one row per step
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	formatFloat := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	for _, step := range r.Steps {
+		err := writer.Write([]string{
+			step.Step,
+			strconv.Itoa(step.Instances),
+			strconv.Itoa(step.Visits),
+			strconv.Itoa(step.Throughput),
+			formatFloat(step.AverageDwell.Seconds()),
+			formatFloat(step.MedianDwell.Seconds()),
+			strconv.Itoa(step.ReworkCount),
+			formatFloat(step.ReworkRate),
+			strconv.Itoa(step.InFlight),
+			strconv.Itoa(step.Abandoned),
+			formatFloat(step.AbandonmentRate),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
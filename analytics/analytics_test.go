@@ -0,0 +1,198 @@
+package analytics_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dracory/swf"
+	"github.com/dracory/swf/analytics"
+)
+
+var start = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// details returns step details started at the given offset, lasting the given duration
+func details(offset time.Duration, duration time.Duration) *swf.StepDetails {
+	d := &swf.StepDetails{
+		Started: start.Add(offset).Format(time.RFC3339),
+		Meta:    map[string]any{},
+	}
+	if duration > 0 {
+		d.Completed = start.Add(offset + duration).Format(time.RFC3339)
+	}
+	return d
+}
+
+func testStates() []*swf.WorkflowState {
+	return []*swf.WorkflowState{
+		// Finished, straight through
+		{
+			CurrentStepName: "approve",
+			History:         []string{"review", "approve"},
+			StepDetails: map[string]*swf.StepDetails{
+				"review":  details(0, time.Hour),
+				"approve": details(time.Hour, 3*time.Hour),
+			},
+		},
+		// Finished, with the review done twice
+		{
+			CurrentStepName: "approve",
+			History:         []string{"review", "approve", "review", "approve"},
+			StepDetails: map[string]*swf.StepDetails{
+				"review":  details(0, 2*time.Hour),
+				"approve": details(2*time.Hour, 5*time.Hour),
+			},
+		},
+		// Stuck at approve for days
+		{
+			CurrentStepName: "approve",
+			History:         []string{"review", "approve"},
+			StepDetails: map[string]*swf.StepDetails{
+				"review":  details(0, 3*time.Hour),
+				"approve": details(3*time.Hour, 0),
+			},
+		},
+		// Just started
+		{
+			CurrentStepName: "review",
+			History:         []string{"review"},
+			StepDetails: map[string]*swf.StepDetails{
+				"review":  details(71*time.Hour, 0),
+				"approve": {Meta: map[string]any{}},
+			},
+		},
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	report := analytics.Analyze(testStates(), analytics.Options{
+		StaleAfter: 24 * time.Hour,
+		Now:        start.Add(72 * time.Hour),
+	})
+
+	if report.Instances != 4 {
+		t.Errorf("Expected 4 instances, got %d", report.Instances)
+	}
+
+	if report.Finished != 2 {
+		t.Errorf("Expected 2 finished instances, got %d", report.Finished)
+	}
+
+	if len(report.Steps) != 2 || report.Steps[0].Step != "review" || report.Steps[1].Step != "approve" {
+		t.Fatalf("Expected steps review, approve, got %v", report.Steps)
+	}
+
+	review := report.Steps[0]
+	if review.Instances != 4 || review.Visits != 5 || review.Throughput != 3 {
+		t.Errorf("Unexpected review counts: %+v", review)
+	}
+
+	if review.AverageDwell != 2*time.Hour || review.MedianDwell != 2*time.Hour {
+		t.Errorf("Expected review dwell 2h, got average %s, median %s", review.AverageDwell, review.MedianDwell)
+	}
+
+	if review.ReworkCount != 1 || review.ReworkRate != 0.25 {
+		t.Errorf("Expected review rework 1 (0.25), got %d (%.2f)", review.ReworkCount, review.ReworkRate)
+	}
+
+	if review.InFlight != 1 || review.Abandoned != 0 {
+		t.Errorf("Expected 1 review in flight and none abandoned, got %d and %d", review.InFlight, review.Abandoned)
+	}
+
+	approve := report.Steps[1]
+	if approve.Instances != 3 || approve.Throughput != 2 {
+		t.Errorf("Unexpected approve counts: %+v", approve)
+	}
+
+	if approve.AverageDwell != 4*time.Hour || approve.MedianDwell != 3*time.Hour {
+		t.Errorf("Expected approve dwell average 4h, median 3h, got %s, %s", approve.AverageDwell, approve.MedianDwell)
+	}
+
+	if approve.Abandoned != 1 || approve.AbandonmentRate != float64(1)/3 {
+		t.Errorf("Expected approve abandoned 1 (0.33), got %d (%.2f)", approve.Abandoned, approve.AbandonmentRate)
+	}
+
+	if report.Bottleneck != "approve" {
+		t.Errorf("Expected bottleneck approve, got %s", report.Bottleneck)
+	}
+}
+
+func TestAnalyzeStepOrder(t *testing.T) {
+	report := analytics.Analyze(testStates(), analytics.Options{
+		Steps: []string{"approve", "review", "archive"},
+	})
+
+	names := []string{}
+	for _, step := range report.Steps {
+		names = append(names, step.Step)
+	}
+
+	if strings.Join(names, ",") != "approve,review,archive" {
+		t.Errorf("Expected steps in given order, got %v", names)
+	}
+
+	// Abandonment detection is disabled
+	if report.Steps[0].Abandoned != 0 {
+		t.Errorf("Expected no abandoned instances, got %d", report.Steps[0].Abandoned)
+	}
+
+	// Empty input
+	empty := analytics.Analyze(nil, analytics.Options{})
+	if empty.Instances != 0 || len(empty.Steps) != 0 {
+		t.Errorf("Expected empty report, got %+v", empty)
+	}
+}
+
+func TestReportToJSON(t *testing.T) {
+	report := analytics.Analyze(testStates(), analytics.Options{})
+
+	data, err := report.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+
+	decoded := struct {
+		Instances  int    `json:"instances"`
+		Bottleneck string `json:"bottleneck"`
+		Steps      []struct {
+			Step               string  `json:"step"`
+			MedianDwellSeconds float64 `json:"median_dwell_seconds"`
+		} `json:"steps"`
+	}{}
+
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	if decoded.Instances != 4 || decoded.Bottleneck != "approve" || len(decoded.Steps) != 2 {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	if decoded.Steps[0].MedianDwellSeconds != 7200 {
+		t.Errorf("Expected review median 7200 seconds, got %.0f", decoded.Steps[0].MedianDwellSeconds)
+	}
+}
+
+func TestReportWriteCSV(t *testing.T) {
+	report := analytics.Analyze(testStates(), analytics.Options{})
+
+	buf := new(bytes.Buffer)
+	if err := report.WriteCSV(buf); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d lines", len(lines))
+	}
+
+	if !strings.HasPrefix(lines[0], "step,instances,visits,throughput,") {
+		t.Errorf("Unexpected header: %s", lines[0])
+	}
+
+	if lines[1] != "review,4,5,3,7200,7200,1,0.25,1,0,0" {
+		t.Errorf("Unexpected review row: %s", lines[1])
+	}
+}
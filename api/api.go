@@ -0,0 +1,593 @@
+// Package api provides a REST API for workflow instances, as an http.Handler.
+//
+// Instances are persisted in a swf.Store, and loaded into the workflow
+// returned by the definition function, which adds the steps:
+//
+//	handler := api.NewHandler(store, func() *swf.Workflow {
+//		wf := swf.NewWorkflow()
+//		wf.AddStep(swf.NewStep("review"))
+//		wf.AddStep(swf.NewStep("approve"))
+//		return wf
+//	})
+//	http.Handle("/api/", http.StripPrefix("/api", handler))
+//
+// Endpoints:
+//
+//	GET    /instances                        list instance IDs
+//	POST   /instances                        create an instance and enter its first step, optional body {"id": "..."} without "/"
+//	GET    /instances/{id}                   get an instance
+//	PUT    /instances/{id}                   replace the state of an instance
+//	DELETE /instances/{id}                   delete an instance
+//	GET    /instances/{id}/current           get the current step
+//	GET    /instances/{id}/progress          get the progress
+//	GET    /instances/{id}/history           get the history
+//	GET    /instances/{id}/meta/{step}       get the metadata of a step
+//	PUT    /instances/{id}/meta/{step}/{key} set a metadata value of a step, body is the JSON value
+//	GET    /instances/{id}/form/{step}       get the form of a step, as a JSON Schema
+//	POST   /instances/{id}/form/{step}       submit the form of a step, body is a JSON object
+//	POST   /instances/{id}/advance           complete the current step and move to the next one
+//	POST   /instances/{id}/complete          complete the current step
+//	POST   /instances/{id}/reject            reject the current step, back to the previous one
+//	GET    /instances/{id}/visualization.dot get the DOT graph
+//	GET    /instances/{id}/visualization.svg get the SVG graph, requires an SVGRenderer
+//
+// Errors are returned as JSON: {"error": {"code": "...", "message": "..."}}
+//
+// Changes load, modify and save the state of the instance. They are serialized
+// within a Handler, but the Store has no revision checks: concurrent changes
+// through several handlers or processes, e.g. concurrent votes on a quorum
+// approval, can overwrite each other. Run a single handler, or lock the
+// instances in your Store.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/dracory/swf"
+)
+
+// Handler is the REST API http.Handler
+type Handler struct {
+	store      swf.Store
+	definition func() *swf.Workflow
+	mux        *http.ServeMux
+
+	// mu serializes the changes, which load, modify and save the state
+	mu sync.Mutex
+
+	// SVGRenderer renders a DOT graph to SVG, e.g. by running Graphviz.
+	// If nil, the SVG visualization endpoint responds 501 Not Implemented.
+	SVGRenderer func(dot string) ([]byte, error)
+
+	// NewID generates the IDs of created instances, when not given by the client.
+	// Defaults to a random 32 characters hex string.
+	NewID func() string
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler creates a new REST API handler
+func NewHandler(store swf.Store, definition func() *swf.Workflow) *Handler {
+	h := &Handler{
+		store:      store,
+		definition: definition,
+		mux:        http.NewServeMux(),
+		NewID:      swf.NewInstanceID,
+	}
+
+	h.mux.HandleFunc("GET /instances", h.listInstances)
+	h.mux.HandleFunc("POST /instances", h.createInstance)
+	h.mux.HandleFunc("GET /instances/{id}", h.getInstance)
+	h.mux.HandleFunc("PUT /instances/{id}", h.updateInstance)
+	h.mux.HandleFunc("DELETE /instances/{id}", h.deleteInstance)
+	h.mux.HandleFunc("GET /instances/{id}/current", h.getCurrentStep)
+	h.mux.HandleFunc("GET /instances/{id}/progress", h.getProgress)
+	h.mux.HandleFunc("GET /instances/{id}/history", h.getHistory)
+	h.mux.HandleFunc("GET /instances/{id}/meta/{step}", h.getStepMeta)
+	h.mux.HandleFunc("PUT /instances/{id}/meta/{step}/{key}", h.setStepMeta)
+	h.mux.HandleFunc("GET /instances/{id}/form/{step}", h.getStepForm)
+	h.mux.HandleFunc("POST /instances/{id}/form/{step}", h.submitStepForm)
+	h.mux.HandleFunc("POST /instances/{id}/advance", h.transition((*swf.Workflow).AdvanceCurrentStep))
+	h.mux.HandleFunc("POST /instances/{id}/complete", h.transition((*swf.Workflow).CompleteCurrentStep))
+	h.mux.HandleFunc("POST /instances/{id}/reject", h.transition((*swf.Workflow).RejectCurrentStep))
+	h.mux.HandleFunc("GET /instances/{id}/visualization.dot", h.getVisualizationDOT)
+	h.mux.HandleFunc("GET /instances/{id}/visualization.svg", h.getVisualizationSVG)
+
+	return h
+}
+
+// ServeHTTP dispatches the request to the endpoint
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// StepResponse is the JSON representation of a step
+type StepResponse struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Responsible string `json:"responsible"`
+}
+
+// ProgressResponse is the JSON representation of the progress
+type ProgressResponse struct {
+	Total     int     `json:"total"`
+	Completed int     `json:"completed"`
+	Current   int     `json:"current"`
+	Pending   int     `json:"pending"`
+	Percents  float64 `json:"percents"`
+}
+
+// InstanceResponse is the JSON representation of an instance
+type InstanceResponse struct {
+	ID          string             `json:"id"`
+	CurrentStep *StepResponse      `json:"current_step"`
+	Progress    ProgressResponse   `json:"progress"`
+	Finished    bool               `json:"finished"`
+	State       *swf.WorkflowState `json:"state"`
+}
+
+// ErrorResponse is the JSON representation of an error
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes an error
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errInvalidRequest marks errors caused by an invalid request body
+var errInvalidRequest = errors.New("invalid request")
+
+// errNotFound marks errors about missing resources other than instances
+var errNotFound = errors.New("not found")
+
+func (h *Handler) listInstances(w http.ResponseWriter, r *http.Request) {
+	ids, err := h.store.InstanceList(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]string{"ids": ids})
+}
+
+func (h *Handler) createInstance(w http.ResponseWriter, r *http.Request) {
+	request := struct {
+		ID string `json:"id"`
+	}{}
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+			writeError(w, fmt.Errorf("%w: %v", errInvalidRequest, err))
+			return
+		}
+	}
+
+	if request.ID == "" {
+		request.ID = h.NewID()
+	}
+
+	// The ID is a path segment of the instance endpoints
+	if strings.Contains(request.ID, "/") || request.ID == "." || request.ID == ".." {
+		writeError(w, fmt.Errorf("%w: invalid instance ID: %q", errInvalidRequest, request.ID))
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	wf := h.definition()
+	if err := h.store.InstanceCreate(r.Context(), request.ID, wf.GetState()); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Enter the first step, e.g. running an automated first step. A failed
+	// automated step is recorded in the state, which is saved, and the
+	// instance is returned with the error in its step details.
+	if wf.GetCurrentStep() != nil {
+		if err := wf.EnterCurrentStep(); err != nil && !errors.Is(err, swf.ErrStepFailed) {
+			writeError(w, err)
+			return
+		}
+
+		if !h.save(w, r, request.ID, wf) {
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusCreated, instanceResponse(request.ID, wf))
+}
+
+func (h *Handler) getInstance(w http.ResponseWriter, r *http.Request) {
+	id, wf, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, instanceResponse(id, wf))
+}
+
+func (h *Handler) updateInstance(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id := r.PathValue("id")
+
+	state := &swf.WorkflowState{}
+	if err := json.NewDecoder(r.Body).Decode(state); err != nil {
+		writeError(w, fmt.Errorf("%w: %v", errInvalidRequest, err))
+		return
+	}
+
+	wf := h.definition()
+	if issues := wf.ValidateState(state); len(issues) > 0 {
+		writeError(w, &swf.StateError{Issues: issues})
+		return
+	}
+	wf.SetState(state)
+
+	if err := h.store.InstanceUpdate(r.Context(), id, state); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, instanceResponse(id, wf))
+}
+
+func (h *Handler) deleteInstance(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.InstanceDelete(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) getCurrentStep(w http.ResponseWriter, r *http.Request) {
+	_, wf, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	current := wf.GetCurrentStep()
+	if current == nil {
+		writeError(w, swf.ErrNoCurrentStep)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stepResponse(current))
+}
+
+func (h *Handler) getProgress(w http.ResponseWriter, r *http.Request) {
+	_, wf, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, progressResponse(wf.GetProgress()))
+}
+
+func (h *Handler) getHistory(w http.ResponseWriter, r *http.Request) {
+	_, wf, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]string{"history": wf.GetState().History})
+}
+
+func (h *Handler) getStepMeta(w http.ResponseWriter, r *http.Request) {
+	_, wf, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	details, err := stepDetails(wf, r.PathValue("step"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, details.Meta)
+}
+
+func (h *Handler) setStepMeta(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id, wf, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	stepName := r.PathValue("step")
+	if _, err := stepDetails(wf, stepName); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var value any
+	if err := json.NewDecoder(r.Body).Decode(&value); err != nil {
+		writeError(w, fmt.Errorf("%w: %v", errInvalidRequest, err))
+		return
+	}
+
+	if err := wf.SetStepMeta(stepName, r.PathValue("key"), value); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !h.save(w, r, id, wf) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, wf.GetState().StepDetails[stepName].Meta)
+}
+
+func (h *Handler) getStepForm(w http.ResponseWriter, r *http.Request) {
+	_, wf, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	stepName := r.PathValue("step")
+	if _, err := stepDetails(wf, stepName); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	form := wf.GetStep(stepName).Form
+	if form == nil {
+		writeError(w, fmt.Errorf("%w: step has no form: %s", errNotFound, stepName))
+		return
+	}
+
+	schema, err := form.ToJSONSchema()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, schema)
+}
+
+func (h *Handler) submitStepForm(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id, wf, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	stepName := r.PathValue("step")
+	if _, err := stepDetails(wf, stepName); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if wf.GetStep(stepName).Form == nil {
+		writeError(w, fmt.Errorf("%w: step has no form: %s", errNotFound, stepName))
+		return
+	}
+
+	data := map[string]any{}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeError(w, fmt.Errorf("%w: %v", errInvalidRequest, err))
+		return
+	}
+
+	if err := wf.SubmitStepForm(stepName, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !h.save(w, r, id, wf) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, wf.GetState().StepDetails[stepName].Meta)
+}
+
+// transition returns an endpoint applying a transition to the instance
+func (h *Handler) transition(apply func(*swf.Workflow) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		id, wf, ok := h.load(w, r)
+		if !ok {
+			return
+		}
+
+		err := apply(wf)
+		if err != nil && !errors.Is(err, swf.ErrStepFailed) {
+			writeError(w, err)
+			return
+		}
+
+		// A failed automated step is recorded in the state, which is saved
+		if !h.save(w, r, id, wf) {
+			return
+		}
+
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, instanceResponse(id, wf))
+	}
+}
+
+func (h *Handler) getVisualizationDOT(w http.ResponseWriter, r *http.Request) {
+	_, wf, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, wf.Visualize())
+}
+
+func (h *Handler) getVisualizationSVG(w http.ResponseWriter, r *http.Request) {
+	if h.SVGRenderer == nil {
+		writeJSON(w, http.StatusNotImplemented, ErrorResponse{Error: ErrorBody{
+			Code:    "not_implemented",
+			Message: "SVG rendering is not configured",
+		}})
+		return
+	}
+
+	_, wf, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+
+	svg, err := h.SVGRenderer(wf.Visualize())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(svg)
+}
+
+// load loads the instance of the request into a new workflow,
+// writing the error response if it fails
+func (h *Handler) load(w http.ResponseWriter, r *http.Request) (string, *swf.Workflow, bool) {
+	id := r.PathValue("id")
+
+	wf := h.definition()
+	if err := swf.LoadInstance(r.Context(), h.store, id, wf); err != nil {
+		writeError(w, err)
+		return "", nil, false
+	}
+
+	return id, wf, true
+}
+
+// save stores the state of the workflow, writing the error response if it fails
+func (h *Handler) save(w http.ResponseWriter, r *http.Request, id string, wf *swf.Workflow) bool {
+	if err := h.store.InstanceUpdate(r.Context(), id, wf.GetState()); err != nil {
+		writeError(w, err)
+		return false
+	}
+
+	return true
+}
+
+// stepDetails returns the details of a step of the workflow
+func stepDetails(wf *swf.Workflow, stepName string) (*swf.StepDetails, error) {
+	if wf.GetStep(stepName) == nil {
+		return nil, fmt.Errorf("%w: %s", swf.ErrStepNotFound, stepName)
+	}
+
+	return wf.GetState().StepDetails[stepName], nil
+}
+
+func instanceResponse(id string, wf *swf.Workflow) InstanceResponse {
+	response := InstanceResponse{
+		ID:       id,
+		Progress: progressResponse(wf.GetProgress()),
+		Finished: wf.IsFinished(),
+		State:    wf.GetState(),
+	}
+
+	if current := wf.GetCurrentStep(); current != nil {
+		step := stepResponse(current)
+		response.CurrentStep = &step
+	}
+
+	return response
+}
+
+func stepResponse(step *swf.Step) StepResponse {
+	return StepResponse{
+		Name:        step.Name,
+		Type:        step.Type,
+		Title:       step.Title,
+		Description: step.Description,
+		Responsible: step.Responsible,
+	}
+}
+
+func progressResponse(progress *swf.Progress) ProgressResponse {
+	return ProgressResponse{
+		Total:     progress.Total,
+		Completed: progress.Completed,
+		Current:   progress.Current,
+		Pending:   progress.Pending,
+		Percents:  progress.Percents,
+	}
+}
+
+// errorStatus maps an error to its HTTP status and error code
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, swf.ErrInstanceNotFound):
+		return http.StatusNotFound, "instance_not_found"
+	case errors.Is(err, swf.ErrStepNotFound):
+		return http.StatusNotFound, "step_not_found"
+	case errors.Is(err, swf.ErrMetaNotFound):
+		return http.StatusNotFound, "meta_not_found"
+	case errors.Is(err, errNotFound):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, swf.ErrInstanceExists):
+		return http.StatusConflict, "instance_exists"
+	case errors.Is(err, swf.ErrWorkflowFinished):
+		return http.StatusConflict, "workflow_finished"
+	case errors.Is(err, swf.ErrNoCurrentStep):
+		return http.StatusConflict, "no_current_step"
+	case errors.Is(err, swf.ErrNoPreviousStep):
+		return http.StatusConflict, "no_previous_step"
+	case errors.Is(err, swf.ErrStepIncomplete):
+		return http.StatusConflict, "step_incomplete"
+	case errors.Is(err, swf.ErrStepFailed):
+		return http.StatusConflict, "step_failed"
+	case errors.Is(err, swf.ErrNotApprover):
+		return http.StatusForbidden, "not_approver"
+	case errors.Is(err, errInvalidRequest):
+		return http.StatusBadRequest, "invalid_request"
+	case errors.Is(err, swf.ErrInvalidStepRef):
+		return http.StatusBadRequest, "invalid_step"
+	case errors.Is(err, swf.ErrFormInvalid):
+		return http.StatusUnprocessableEntity, "invalid_form"
+	case errors.Is(err, swf.ErrInvalidState):
+		return http.StatusUnprocessableEntity, "invalid_state"
+	default:
+		return http.StatusInternalServerError, "internal_error"
+	}
+}
+
+// writeError writes an error as a JSON response
+func writeError(w http.ResponseWriter, err error) {
+	status, code := errorStatus(err)
+	writeJSON(w, status, ErrorResponse{Error: ErrorBody{
+		Code:    code,
+		Message: err.Error(),
+	}})
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
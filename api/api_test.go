@@ -0,0 +1,417 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dracory/swf"
+	"github.com/dracory/swf/api"
+)
+
+func definition() *swf.Workflow {
+	wf := swf.NewWorkflow()
+
+	review := swf.NewStep("review")
+	review.Title = "Review"
+
+	approve := swf.NewStep("approve")
+	approve.Title = "Approve"
+	approve.Responsible = "manager"
+
+	wf.AddStep(review)
+	wf.AddStep(approve)
+	return wf
+}
+
+func newHandler() (*api.Handler, *swf.MemoryStore) {
+	store := swf.NewMemoryStore()
+	handler := api.NewHandler(store, definition)
+	return handler, store
+}
+
+// do performs a request against the handler, decoding the JSON response into out
+func do(t *testing.T, handler http.Handler, method string, path string, body string, out any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if out != nil {
+		if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+			t.Fatalf("Invalid JSON response %s: %v", recorder.Body.String(), err)
+		}
+	}
+
+	return recorder
+}
+
+func TestInstanceCRUD(t *testing.T) {
+	handler, _ := newHandler()
+
+	created := api.InstanceResponse{}
+	response := do(t, handler, http.MethodPost, "/instances", `{"id":"doc-1"}`, &created)
+	if response.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", response.Code, response.Body.String())
+	}
+
+	if created.ID != "doc-1" || created.CurrentStep == nil || created.CurrentStep.Name != "review" {
+		t.Errorf("Unexpected created instance: %+v", created)
+	}
+
+	// Generated ID
+	generated := api.InstanceResponse{}
+	do(t, handler, http.MethodPost, "/instances", "", &generated)
+	if len(generated.ID) != 32 {
+		t.Errorf("Expected generated ID, got %q", generated.ID)
+	}
+
+	// Duplicate ID
+	errorResponse := api.ErrorResponse{}
+	response = do(t, handler, http.MethodPost, "/instances", `{"id":"doc-1"}`, &errorResponse)
+	if response.Code != http.StatusConflict || errorResponse.Error.Code != "instance_exists" {
+		t.Errorf("Expected 409 instance_exists, got %d %+v", response.Code, errorResponse)
+	}
+
+	// IDs unreachable as a path segment
+	for _, id := range []string{"doc/1", "..", "."} {
+		errorResponse = api.ErrorResponse{}
+		response = do(t, handler, http.MethodPost, "/instances", fmt.Sprintf(`{"id":%q}`, id), &errorResponse)
+		if response.Code != http.StatusBadRequest || errorResponse.Error.Code != "invalid_request" {
+			t.Errorf("Expected 400 invalid_request for %q, got %d %+v", id, response.Code, errorResponse)
+		}
+	}
+
+	list := struct {
+		IDs []string `json:"ids"`
+	}{}
+	do(t, handler, http.MethodGet, "/instances", "", &list)
+	if len(list.IDs) != 2 {
+		t.Errorf("Expected 2 instances, got %v", list.IDs)
+	}
+
+	instance := api.InstanceResponse{}
+	response = do(t, handler, http.MethodGet, "/instances/doc-1", "", &instance)
+	if response.Code != http.StatusOK || instance.Progress.Total != 2 {
+		t.Errorf("Unexpected instance: %d %+v", response.Code, instance)
+	}
+
+	// Replace the state
+	wf := definition()
+	wf.SetCurrentStep("approve")
+	state, _ := wf.ToString()
+
+	response = do(t, handler, http.MethodPut, "/instances/doc-1", state, &instance)
+	if response.Code != http.StatusOK || instance.CurrentStep.Name != "approve" {
+		t.Errorf("Expected updated instance at approve, got %d %+v", response.Code, instance)
+	}
+
+	// States not matching the definition are rejected
+	response = do(t, handler, http.MethodPut, "/instances/doc-1", `{"CurrentStepName":"unknown"}`, &errorResponse)
+	if response.Code != http.StatusUnprocessableEntity || errorResponse.Error.Code != "invalid_state" {
+		t.Errorf("Expected 422 invalid_state, got %d %+v", response.Code, errorResponse)
+	}
+
+	unknownHistory := strings.TrimSuffix(strings.TrimSpace(state), "}") + `,"History":["archived"]}`
+	response = do(t, handler, http.MethodPut, "/instances/doc-1", unknownHistory, &errorResponse)
+	if response.Code != http.StatusUnprocessableEntity || !strings.Contains(errorResponse.Error.Message, "archived") {
+		t.Errorf("Expected 422 for an unknown history step, got %d %+v", response.Code, errorResponse)
+	}
+
+	response = do(t, handler, http.MethodPut, "/instances/doc-1", `{`, &errorResponse)
+	if response.Code != http.StatusBadRequest || errorResponse.Error.Code != "invalid_request" {
+		t.Errorf("Expected 400 invalid_request, got %d %+v", response.Code, errorResponse)
+	}
+
+	response = do(t, handler, http.MethodDelete, "/instances/doc-1", "", nil)
+	if response.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", response.Code)
+	}
+
+	response = do(t, handler, http.MethodGet, "/instances/doc-1", "", &errorResponse)
+	if response.Code != http.StatusNotFound || errorResponse.Error.Code != "instance_not_found" {
+		t.Errorf("Expected 404 instance_not_found, got %d %+v", response.Code, errorResponse)
+	}
+}
+
+func TestInstanceTransitions(t *testing.T) {
+	handler, _ := newHandler()
+	do(t, handler, http.MethodPost, "/instances", `{"id":"doc-1"}`, nil)
+
+	errorResponse := api.ErrorResponse{}
+	response := do(t, handler, http.MethodPost, "/instances/doc-1/reject", "", &errorResponse)
+	if response.Code != http.StatusConflict || errorResponse.Error.Code != "no_previous_step" {
+		t.Errorf("Expected 409 no_previous_step, got %d %+v", response.Code, errorResponse)
+	}
+
+	instance := api.InstanceResponse{}
+	do(t, handler, http.MethodPost, "/instances/doc-1/advance", "", &instance)
+	if instance.CurrentStep.Name != "approve" || instance.Progress.Completed != 1 {
+		t.Errorf("Expected instance at approve, got %+v", instance)
+	}
+
+	do(t, handler, http.MethodPost, "/instances/doc-1/reject", "", &instance)
+	if instance.CurrentStep.Name != "review" {
+		t.Errorf("Expected instance back at review, got %+v", instance)
+	}
+
+	do(t, handler, http.MethodPost, "/instances/doc-1/advance", "", nil)
+	do(t, handler, http.MethodPost, "/instances/doc-1/complete", "", &instance)
+	if !instance.Finished || instance.Progress.Completed != 2 {
+		t.Errorf("Expected finished instance, got %+v", instance)
+	}
+
+	response = do(t, handler, http.MethodPost, "/instances/doc-1/advance", "", &errorResponse)
+	if response.Code != http.StatusConflict || errorResponse.Error.Code != "workflow_finished" {
+		t.Errorf("Expected 409 workflow_finished, got %d %+v", response.Code, errorResponse)
+	}
+
+	current := api.StepResponse{}
+	do(t, handler, http.MethodGet, "/instances/doc-1/current", "", &current)
+	if current.Name != "approve" || current.Responsible != "manager" {
+		t.Errorf("Unexpected current step: %+v", current)
+	}
+
+	progress := api.ProgressResponse{}
+	do(t, handler, http.MethodGet, "/instances/doc-1/progress", "", &progress)
+	if progress.Percents != 100 {
+		t.Errorf("Expected 100%% progress, got %+v", progress)
+	}
+
+	history := struct {
+		History []string `json:"history"`
+	}{}
+	do(t, handler, http.MethodGet, "/instances/doc-1/history", "", &history)
+	if strings.Join(history.History, ",") != "review,approve,review,approve" {
+		t.Errorf("Unexpected history: %v", history.History)
+	}
+}
+
+func TestInstanceFailedAutomatedStep(t *testing.T) {
+	automated := swf.NewAutomatedStepType()
+	automated.Handle("generate", func(w *swf.Workflow, step *swf.Step) error {
+		return errors.New("ERP unavailable")
+	})
+
+	registry := swf.NewStepTypeRegistry()
+	registry.Register(swf.StepTypeAutomated, automated)
+
+	store := swf.NewMemoryStore()
+	handler := api.NewHandler(store, func() *swf.Workflow {
+		wf := swf.NewWorkflow()
+		wf.SetStepTypes(registry)
+
+		generate := swf.NewStep("generate")
+		generate.Type = swf.StepTypeAutomated
+
+		wf.AddStep(swf.NewStep("review"))
+		wf.AddStep(generate)
+		return wf
+	})
+
+	do(t, handler, http.MethodPost, "/instances", `{"id":"doc-1"}`, nil)
+
+	errorResponse := api.ErrorResponse{}
+	response := do(t, handler, http.MethodPost, "/instances/doc-1/advance", "", &errorResponse)
+	if response.Code != http.StatusConflict || errorResponse.Error.Code != "step_failed" {
+		t.Errorf("Expected 409 step_failed, got %d %+v", response.Code, errorResponse)
+	}
+
+	state, _ := store.InstanceFindByID(t.Context(), "doc-1")
+	if state.CurrentStepName != "generate" {
+		t.Errorf("Expected the transition to be saved, got %s", state.CurrentStepName)
+	}
+
+	if details := state.StepDetails["generate"]; details.Attempts != 1 || details.Error != "ERP unavailable" {
+		t.Errorf("Expected the failure to be saved, got %+v", details)
+	}
+}
+
+func TestInstanceAutomatedFirstStep(t *testing.T) {
+	runs := 0
+	failing := false
+
+	automated := swf.NewAutomatedStepType()
+	automated.Handle("generate", func(w *swf.Workflow, step *swf.Step) error {
+		runs++
+		if failing {
+			return errors.New("ERP unavailable")
+		}
+		return nil
+	})
+
+	registry := swf.NewStepTypeRegistry()
+	registry.Register(swf.StepTypeAutomated, automated)
+
+	store := swf.NewMemoryStore()
+	handler := api.NewHandler(store, func() *swf.Workflow {
+		wf := swf.NewWorkflow()
+		wf.SetStepTypes(registry)
+
+		generate := swf.NewStep("generate")
+		generate.Type = swf.StepTypeAutomated
+
+		wf.AddStep(generate)
+		wf.AddStep(swf.NewStep("review"))
+		return wf
+	})
+
+	created := api.InstanceResponse{}
+	response := do(t, handler, http.MethodPost, "/instances", `{"id":"doc-1"}`, &created)
+	if response.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", response.Code, response.Body.String())
+	}
+
+	if runs != 1 || created.CurrentStep == nil || created.CurrentStep.Name != "review" {
+		t.Errorf("Expected the handler to run once and the instance at review, got %d runs and %+v", runs, created.CurrentStep)
+	}
+
+	state, _ := store.InstanceFindByID(t.Context(), "doc-1")
+	if state.CurrentStepName != "review" {
+		t.Errorf("Expected the entered step to be saved, got %s", state.CurrentStepName)
+	}
+
+	// A failed first step is saved, and the instance created
+	failing = true
+	response = do(t, handler, http.MethodPost, "/instances", `{"id":"doc-2"}`, &created)
+	if response.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", response.Code, response.Body.String())
+	}
+
+	state, _ = store.InstanceFindByID(t.Context(), "doc-2")
+	if details := state.StepDetails["generate"]; state.CurrentStepName != "generate" || details.Attempts != 1 || details.Error != "ERP unavailable" {
+		t.Errorf("Expected the failure to be saved, got %s %+v", state.CurrentStepName, details)
+	}
+}
+
+func TestInstanceMeta(t *testing.T) {
+	handler, _ := newHandler()
+	do(t, handler, http.MethodPost, "/instances", `{"id":"doc-1"}`, nil)
+
+	meta := map[string]any{}
+	response := do(t, handler, http.MethodPut, "/instances/doc-1/meta/review/amount", `120`, &meta)
+	if response.Code != http.StatusOK || meta["amount"] != float64(120) {
+		t.Errorf("Expected amount 120, got %d %v", response.Code, meta)
+	}
+
+	meta = map[string]any{}
+	do(t, handler, http.MethodGet, "/instances/doc-1/meta/review", "", &meta)
+	if meta["amount"] != float64(120) {
+		t.Errorf("Expected stored amount 120, got %v", meta)
+	}
+
+	errorResponse := api.ErrorResponse{}
+	response = do(t, handler, http.MethodGet, "/instances/doc-1/meta/unknown", "", &errorResponse)
+	if response.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown step, got %d", response.Code)
+	}
+
+	response = do(t, handler, http.MethodPut, "/instances/doc-1/meta/review/amount", `{`, &errorResponse)
+	if response.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid value, got %d", response.Code)
+	}
+}
+
+func TestInstanceConcurrentChanges(t *testing.T) {
+	handler, store := newHandler()
+	do(t, handler, http.MethodPost, "/instances", `{"id":"doc-1"}`, nil)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			do(t, handler, http.MethodPut, fmt.Sprintf("/instances/doc-1/meta/review/key%d", i), "true", nil)
+		}()
+	}
+	wg.Wait()
+
+	state, _ := store.InstanceFindByID(t.Context(), "doc-1")
+	if len(state.StepDetails["review"].Meta) != 20 {
+		t.Errorf("Expected the 20 concurrent changes to be kept, got %v", state.StepDetails["review"].Meta)
+	}
+}
+
+func TestInstanceForm(t *testing.T) {
+	handler := api.NewHandler(swf.NewMemoryStore(), func() *swf.Workflow {
+		wf := definition()
+		wf.GetStep("review").Form = &swf.FormSchema{Fields: []*swf.FormField{
+			{Name: "amount", Type: swf.FormFieldTypeNumber, Required: true},
+		}}
+		return wf
+	})
+	do(t, handler, http.MethodPost, "/instances", `{"id":"doc-1"}`, nil)
+
+	response := do(t, handler, http.MethodGet, "/instances/doc-1/form/review", "", nil)
+	if response.Code != http.StatusOK || !strings.Contains(response.Body.String(), `"required": [`) {
+		t.Errorf("Expected JSON Schema, got %d %s", response.Code, response.Body.String())
+	}
+
+	errorResponse := api.ErrorResponse{}
+	response = do(t, handler, http.MethodGet, "/instances/doc-1/form/approve", "", &errorResponse)
+	if response.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for step without form, got %d", response.Code)
+	}
+
+	// The step cannot be completed before the form is submitted
+	response = do(t, handler, http.MethodPost, "/instances/doc-1/advance", "", &errorResponse)
+	if response.Code != http.StatusUnprocessableEntity || errorResponse.Error.Code != "invalid_form" {
+		t.Errorf("Expected 422 invalid_form, got %d %v", response.Code, errorResponse)
+	}
+
+	response = do(t, handler, http.MethodPost, "/instances/doc-1/form/review", `{"amount":"abc"}`, &errorResponse)
+	if response.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for invalid data, got %d", response.Code)
+	}
+
+	meta := map[string]any{}
+	response = do(t, handler, http.MethodPost, "/instances/doc-1/form/review", `{"amount":120.5}`, &meta)
+	if response.Code != http.StatusOK || meta["amount"] != 120.5 {
+		t.Errorf("Expected amount 120.5, got %d %v", response.Code, meta)
+	}
+
+	instance := api.InstanceResponse{}
+	response = do(t, handler, http.MethodPost, "/instances/doc-1/advance", "", &instance)
+	if response.Code != http.StatusOK || instance.CurrentStep.Name != "approve" {
+		t.Errorf("Expected to advance to approve, got %d", response.Code)
+	}
+}
+
+func TestInstanceVisualization(t *testing.T) {
+	handler, _ := newHandler()
+	do(t, handler, http.MethodPost, "/instances", `{"id":"doc-1"}`, nil)
+
+	response := do(t, handler, http.MethodGet, "/instances/doc-1/visualization.dot", "", nil)
+	if response.Code != http.StatusOK || !strings.Contains(response.Body.String(), "digraph") {
+		t.Errorf("Expected DOT graph, got %d %s", response.Code, response.Body.String())
+	}
+
+	response = do(t, handler, http.MethodGet, "/instances/doc-1/visualization.svg", "", nil)
+	if response.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501 without SVG renderer, got %d", response.Code)
+	}
+
+	handler.SVGRenderer = func(dot string) ([]byte, error) {
+		return []byte("<svg></svg>"), nil
+	}
+
+	response = do(t, handler, http.MethodGet, "/instances/doc-1/visualization.svg", "", nil)
+	if response.Code != http.StatusOK || response.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Errorf("Expected SVG, got %d %s", response.Code, response.Header().Get("Content-Type"))
+	}
+
+	handler.SVGRenderer = func(dot string) ([]byte, error) {
+		return nil, errors.New("dot not installed")
+	}
+
+	response = do(t, handler, http.MethodGet, "/instances/doc-1/visualization.svg", "", nil)
+	if response.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 for failing renderer, got %d", response.Code)
+	}
+}
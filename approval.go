@@ -0,0 +1,230 @@
+package swf
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// ApprovalRule is the quorum rule of an approval step, e.g. "2 of 3 directors"
+// or "all of legal"
+type ApprovalRule struct {
+	// Approvers lists the actors allowed to vote, anyone can vote if empty
+	Approvers []string `json:",omitempty"`
+
+	// Quorum is the number of approvals completing the step.
+	// Defaults to all the approvers, or 1 if anyone can vote.
+	Quorum int `json:",omitempty"`
+
+	// Vetoes is the number of rejections rejecting the step, defaults to 1.
+	// The step is also rejected when the quorum can no longer be reached.
+	Vetoes int `json:",omitempty"`
+}
+
+// Vote is an approve or reject vote on an approval step
+type Vote struct {
+	Actor string
+
+	// Decision is ApprovalActionApprove or ApprovalActionReject
+	Decision string
+
+	Comment string `json:",omitempty"`
+	Time    string
+
+	// Activation is the length of the workflow history when the vote was cast,
+	// votes of previous activations of the step are not counted
+	Activation int
+}
+
+// ApprovalTally is the count of the votes of the current activation of an approval step
+type ApprovalTally struct {
+	Approvals  int
+	Rejections int
+
+	// Required is the number of approvals needed
+	Required int
+
+	// Pending lists the approvers who have not voted yet
+	Pending []string
+
+	// Approved is true when the quorum is reached
+	Approved bool
+
+	// Rejected is true when the veto rule triggered, or the quorum can no longer be reached
+	Rejected bool
+}
+
+// String describes the tally, e.g. "2/3 approvals, 0 rejections, pending: [carol]"
+func (t *ApprovalTally) String() string {
+	text := fmt.Sprintf("%d/%d approvals, %d rejections", t.Approvals, t.Required, t.Rejections)
+	if len(t.Pending) > 0 {
+		text += fmt.Sprintf(", pending: %v", t.Pending)
+	}
+	return text
+}
+
+// GetApprovalRule returns the quorum rule of the step, defaulting to one approval by anyone
+func (s *Step) GetApprovalRule() *ApprovalRule {
+	if s.Approval == nil {
+		return &ApprovalRule{}
+	}
+	return s.Approval
+}
+
+// GetApprovalTally counts the votes of the current activation of an approval step
+//
+// Business logic:
+// 1. Keep the last vote of each actor in the current activation
+// 2. Count approvals and rejections
+// 3. Approved when the approvals reach the quorum
+// 4. Rejected when the rejections reach the vetoes, or the quorum is out of reach
+func (w *Workflow) GetApprovalTally(step any) (*ApprovalTally, error) {
+	stepName, details, err := w.stepDetails(step)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := w.GetStep(stepName)
+	if definition == nil {
+		return nil, fmt.Errorf("%w: %s", ErrStepNotFound, stepName)
+	}
+
+	return approvalTally(definition.GetApprovalRule(), details.Votes, len(w.state.History)), nil
+}
+
+// approvalTally counts the votes of an activation of an approval step
+func approvalTally(rule *ApprovalRule, allVotes []*Vote, activation int) *ApprovalTally {
+	votes := map[string]*Vote{}
+	for _, vote := range allVotes {
+		if vote.Activation == activation {
+			votes[vote.Actor] = vote
+		}
+	}
+
+	tally := &ApprovalTally{
+		Required: rule.Quorum,
+		Pending:  []string{},
+	}
+
+	if tally.Required <= 0 {
+		tally.Required = max(len(rule.Approvers), 1)
+	}
+
+	for _, vote := range votes {
+		if vote.Decision == ApprovalActionApprove {
+			tally.Approvals++
+		} else {
+			tally.Rejections++
+		}
+	}
+
+	for _, approver := range rule.Approvers {
+		if votes[approver] == nil {
+			tally.Pending = append(tally.Pending, approver)
+		}
+	}
+
+	vetoes := rule.Vetoes
+	if vetoes <= 0 {
+		vetoes = 1
+	}
+
+	outOfReach := len(rule.Approvers) > 0 && tally.Approvals+len(tally.Pending) < tally.Required
+
+	tally.Approved = tally.Approvals >= tally.Required
+	tally.Rejected = !tally.Approved && (tally.Rejections >= vetoes || outOfReach)
+
+	return tally
+}
+
+// Vote records an approve or reject vote on the current approval step,
+// completing or rejecting the step when the quorum rule is met
+//
+// Business logic:
+// 1. Check the step is the current approval step and the actor is an approver
+// 2. Count the votes with this vote, replacing a previous vote of the actor in the same activation
+// 3. Before recording anything, check the form for an approval, or the previous step for a rejection
+// 4. Record the vote, and report the tally as the partial progress of the step
+// 5. If approved, record "approved_by" and advance the workflow
+// 6. If rejected, record "rejected_by" and send the workflow back to the previous step
+func (w *Workflow) Vote(step any, actor string, decision string, comment string) (*ApprovalTally, error) {
+	stepName, details, err := w.stepDetails(step)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := w.GetStep(stepName)
+	if definition == nil {
+		return nil, fmt.Errorf("%w: %s", ErrStepNotFound, stepName)
+	}
+
+	if !isApprovalStep(w, definition) {
+		return nil, fmt.Errorf("%w: %s", ErrNotApprovalStep, stepName)
+	}
+
+	if !w.IsStepCurrent(stepName) || w.IsFinished() {
+		return nil, fmt.Errorf("%w: %s", ErrStepNotCurrent, stepName)
+	}
+
+	if decision != ApprovalActionApprove && decision != ApprovalActionReject {
+		return nil, fmt.Errorf("invalid vote decision: %s", decision)
+	}
+
+	rule := definition.GetApprovalRule()
+	if actor == "" || (len(rule.Approvers) > 0 && !lo.Contains(rule.Approvers, actor)) {
+		return nil, fmt.Errorf("%w: %s on step %s", ErrNotApprover, actor, stepName)
+	}
+
+	activation := len(w.state.History)
+	votes := lo.Reject(details.Votes, func(vote *Vote, index int) bool {
+		return vote.Activation == activation && vote.Actor == actor
+	})
+	votes = append(votes, &Vote{
+		Actor:      actor,
+		Decision:   decision,
+		Comment:    comment,
+		Time:       time.Now().Format(time.RFC3339),
+		Activation: activation,
+	})
+
+	tally := approvalTally(rule, votes, activation)
+
+	if tally.Approved {
+		if err := w.ValidateStepForm(stepName); err != nil {
+			return nil, err
+		}
+	}
+
+	if tally.Rejected && w.GetPreviousStep() == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoPreviousStep, stepName)
+	}
+
+	details.Votes = votes
+
+	details.Progress = &StepProgress{
+		Done:  min(tally.Approvals, tally.Required),
+		Total: tally.Required,
+	}
+
+	switch {
+	case tally.Approved:
+		w.setStepMeta(stepName, "approved_by", actor, actor)
+		return tally, w.AdvanceCurrentStep()
+	case tally.Rejected:
+		w.setStepMeta(stepName, "rejected_by", actor, actor)
+		details.Progress = nil
+		return tally, w.RejectCurrentStep()
+	}
+
+	return tally, nil
+}
+
+// isApprovalStep checks if a step behaves as an approval step
+func isApprovalStep(w *Workflow, step *Step) bool {
+	switch w.GetStepType(step).(type) {
+	case ApprovalStepType, *ApprovalStepType:
+		return true
+	}
+	return false
+}
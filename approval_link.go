@@ -0,0 +1,216 @@
+package swf
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Approval link actions
+const (
+	ApprovalActionApprove = "approve"
+	ApprovalActionReject  = "reject"
+)
+
+// DefaultApprovalLinkTTL is the default validity of approval links
+const DefaultApprovalLinkTTL = 72 * time.Hour
+
+// Approval link query parameters
+const (
+	approvalParamInstance   = "instance"
+	approvalParamStep       = "step"
+	approvalParamActor      = "actor"
+	approvalParamAction     = "action"
+	approvalParamActivation = "activation"
+	approvalParamExpires    = "expires"
+	approvalParamSign       = "signature"
+)
+
+// ApprovalLinks generates and verifies HMAC-signed, time-limited links
+// to approve or reject the current step of a workflow instance, e.g. from an email.
+//
+// A link is bound to the activation of the step (its position in the history),
+// so it can only be used while the step is current: once the workflow has moved
+// on, even if it later comes back to the same step, the link is rejected.
+type ApprovalLinks struct {
+	// BaseURL is the URL of the endpoint handling the links,
+	// e.g. "https://example.com/approvals"
+	BaseURL string
+
+	// Secret is the key used to sign the links
+	Secret []byte
+
+	// TTL is the validity of the links, defaults to DefaultApprovalLinkTTL
+	TTL time.Duration
+
+	// Now returns the current time, defaults to time.Now
+	Now func() time.Time
+}
+
+// ApprovalClaim is the verified content of an approval link
+type ApprovalClaim struct {
+	InstanceID string
+	StepName   string
+	Actor      string
+	Action     string
+	Activation int
+	Expires    time.Time
+}
+
+// Build returns an approval link for the current step of a workflow instance
+//
+// Business logic:
+// 1. Check the action, and that the step is the current step
+// 2. Bind the link to the activation of the step, and set its expiry
+// 3. Sign all parameters
+func (l *ApprovalLinks) Build(instanceID string, w *Workflow, stepName string, actor string, action string) (string, error) {
+	if len(l.Secret) == 0 {
+		return "", fmt.Errorf("approval links require a secret")
+	}
+
+	if action != ApprovalActionApprove && action != ApprovalActionReject {
+		return "", fmt.Errorf("invalid approval action: %s", action)
+	}
+
+	if !w.IsStepCurrent(stepName) {
+		return "", fmt.Errorf("%w: %s", ErrStepNotCurrent, stepName)
+	}
+
+	claim := &ApprovalClaim{
+		InstanceID: instanceID,
+		StepName:   stepName,
+		Actor:      actor,
+		Action:     action,
+		Activation: len(w.state.History),
+		Expires:    l.now().Add(l.ttl()).Truncate(time.Second),
+	}
+
+	query := url.Values{}
+	query.Set(approvalParamInstance, claim.InstanceID)
+	query.Set(approvalParamStep, claim.StepName)
+	query.Set(approvalParamActor, claim.Actor)
+	query.Set(approvalParamAction, claim.Action)
+	query.Set(approvalParamActivation, strconv.Itoa(claim.Activation))
+	query.Set(approvalParamExpires, strconv.FormatInt(claim.Expires.Unix(), 10))
+	query.Set(approvalParamSign, signHMAC(l.Secret, claim.payload()))
+
+	return l.BaseURL + "?" + query.Encode(), nil
+}
+
+// Verify checks the signature and expiry of an approval link,
+// given its query parameters, and returns its claim
+func (l *ApprovalLinks) Verify(query url.Values) (*ApprovalClaim, error) {
+	if len(l.Secret) == 0 {
+		return nil, fmt.Errorf("approval links require a secret")
+	}
+
+	expires, err := strconv.ParseInt(query.Get(approvalParamExpires), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid expiry", ErrLinkInvalid)
+	}
+
+	activation, err := strconv.Atoi(query.Get(approvalParamActivation))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid activation", ErrLinkInvalid)
+	}
+
+	claim := &ApprovalClaim{
+		InstanceID: query.Get(approvalParamInstance),
+		StepName:   query.Get(approvalParamStep),
+		Actor:      query.Get(approvalParamActor),
+		Action:     query.Get(approvalParamAction),
+		Activation: activation,
+		Expires:    time.Unix(expires, 0),
+	}
+
+	signature := signHMAC(l.Secret, claim.payload())
+	if !hmac.Equal([]byte(signature), []byte(query.Get(approvalParamSign))) {
+		return nil, fmt.Errorf("%w: invalid signature", ErrLinkInvalid)
+	}
+
+	if !l.now().Before(claim.Expires) {
+		return nil, ErrLinkExpired
+	}
+
+	return claim, nil
+}
+
+// Apply applies the action of a verified claim to the workflow instance
+//
+// Business logic:
+// 1. Check that the step is still current, in the same activation as when the link was built
+// 2. For steps with a quorum rule, record the action as a vote (see Workflow.Vote)
+// 3. Otherwise record the actor in the step metadata ("approved_by" or "rejected_by")
+// 4. Approve advances the workflow, reject sends it back to the previous step
+func (l *ApprovalLinks) Apply(claim *ApprovalClaim, w *Workflow) error {
+	if !w.IsStepCurrent(claim.StepName) || len(w.state.History) != claim.Activation || w.IsFinished() {
+		return fmt.Errorf("%w: %s", ErrStepNotCurrent, claim.StepName)
+	}
+
+	// Approval steps with a quorum rule count the link as a vote
+	if step := w.GetStep(claim.StepName); step != nil && step.Approval != nil && isApprovalStep(w, step) {
+		_, err := w.Vote(claim.StepName, claim.Actor, claim.Action, "")
+		return err
+	}
+
+	switch claim.Action {
+	case ApprovalActionApprove:
+		w.SetStepMetaBy(claim.StepName, "approved_by", claim.Actor, claim.Actor)
+		return w.AdvanceCurrentStep()
+	case ApprovalActionReject:
+		w.SetStepMetaBy(claim.StepName, "rejected_by", claim.Actor, claim.Actor)
+		return w.RejectCurrentStep()
+	default:
+		return fmt.Errorf("%w: invalid action %s", ErrLinkInvalid, claim.Action)
+	}
+}
+
+// VerifyAndApply verifies an approval link and applies it to the workflow instance,
+// which the caller loaded by the instance ID of the link
+func (l *ApprovalLinks) VerifyAndApply(query url.Values, w *Workflow) (*ApprovalClaim, error) {
+	claim, err := l.Verify(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return claim, l.Apply(claim, w)
+}
+
+// payload returns the signed content of the claim,
+// length-prefixing each value so that values cannot be shifted between fields
+func (c *ApprovalClaim) payload() string {
+	values := []string{
+		c.InstanceID,
+		c.StepName,
+		c.Actor,
+		c.Action,
+		strconv.Itoa(c.Activation),
+		strconv.FormatInt(c.Expires.Unix(), 10),
+	}
+
+	builder := strings.Builder{}
+	for _, value := range values {
+		builder.WriteString(strconv.Itoa(len(value)) + ":" + value + ";")
+	}
+
+	return builder.String()
+}
+
+// now returns the current time
+func (l *ApprovalLinks) now() time.Time {
+	if l.Now != nil {
+		return l.Now()
+	}
+	return time.Now()
+}
+
+// ttl returns the validity of the links
+func (l *ApprovalLinks) ttl() time.Duration {
+	if l.TTL > 0 {
+		return l.TTL
+	}
+	return DefaultApprovalLinkTTL
+}
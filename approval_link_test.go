@@ -0,0 +1,147 @@
+package swf_test
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dracory/swf"
+)
+
+// linkQuery returns the query parameters of a link
+func linkQuery(t *testing.T, link string) url.Values {
+	t.Helper()
+
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("Invalid link %s: %v", link, err)
+	}
+
+	return parsed.Query()
+}
+
+func TestApprovalLinksApprove(t *testing.T) {
+	links := &swf.ApprovalLinks{
+		BaseURL: "https://example.com/approvals",
+		Secret:  []byte("secret"),
+	}
+
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("submit"))
+	wf.AddStep(swf.NewStep("approve"))
+	wf.AddStep(swf.NewStep("publish"))
+	wf.SetCurrentStep("approve")
+
+	link, err := links.Build("doc-1", wf, "approve", "jane@example.com", swf.ApprovalActionApprove)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if !strings.HasPrefix(link, "https://example.com/approvals?") {
+		t.Errorf("Unexpected link %s", link)
+	}
+
+	claim, err := links.VerifyAndApply(linkQuery(t, link), wf)
+	if err != nil {
+		t.Fatalf("VerifyAndApply failed: %v", err)
+	}
+
+	if claim.InstanceID != "doc-1" || claim.Actor != "jane@example.com" {
+		t.Errorf("Unexpected claim %+v", claim)
+	}
+
+	if wf.GetState().CurrentStepName != "publish" {
+		t.Errorf("Expected workflow at publish, got %s", wf.GetState().CurrentStepName)
+	}
+
+	if wf.GetStepMeta("approve", "approved_by") != "jane@example.com" {
+		t.Errorf("Expected approver to be recorded, got %v", wf.GetStepMeta("approve", "approved_by"))
+	}
+
+	// Replay after the workflow has moved on
+	if _, err := links.VerifyAndApply(linkQuery(t, link), wf); !errors.Is(err, swf.ErrStepNotCurrent) {
+		t.Errorf("Expected ErrStepNotCurrent on replay, got %v", err)
+	}
+}
+
+func TestApprovalLinksReject(t *testing.T) {
+	links := &swf.ApprovalLinks{Secret: []byte("secret")}
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("submit"))
+	wf.AddStep(swf.NewStep("approve"))
+	wf.AddStep(swf.NewStep("publish"))
+	wf.SetCurrentStep("approve")
+
+	link, err := links.Build("doc-1", wf, "approve", "jane", swf.ApprovalActionReject)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if _, err := links.VerifyAndApply(linkQuery(t, link), wf); err != nil {
+		t.Fatalf("VerifyAndApply failed: %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "submit" {
+		t.Errorf("Expected workflow back at submit, got %s", wf.GetState().CurrentStepName)
+	}
+
+	// The step is current again, but in a new activation
+	wf.AdvanceCurrentStep()
+	if _, err := links.VerifyAndApply(linkQuery(t, link), wf); !errors.Is(err, swf.ErrStepNotCurrent) {
+		t.Errorf("Expected ErrStepNotCurrent for an old activation, got %v", err)
+	}
+}
+
+func TestApprovalLinksVerify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	links := &swf.ApprovalLinks{
+		Secret: []byte("secret"),
+		TTL:    time.Hour,
+		Now:    func() time.Time { return now },
+	}
+
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("submit"))
+	wf.AddStep(swf.NewStep("approve"))
+	wf.AddStep(swf.NewStep("publish"))
+	wf.SetCurrentStep("approve")
+
+	link, err := links.Build("doc-1", wf, "approve", "jane", swf.ApprovalActionApprove)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	// Tampered parameters
+	query := linkQuery(t, link)
+	query.Set("actor", "mallory")
+	if _, err := links.Verify(query); !errors.Is(err, swf.ErrLinkInvalid) {
+		t.Errorf("Expected ErrLinkInvalid for tampered link, got %v", err)
+	}
+
+	// Other secret
+	other := &swf.ApprovalLinks{Secret: []byte("other"), Now: links.Now}
+	if _, err := other.Verify(linkQuery(t, link)); !errors.Is(err, swf.ErrLinkInvalid) {
+		t.Errorf("Expected ErrLinkInvalid for other secret, got %v", err)
+	}
+
+	// Expired
+	now = now.Add(2 * time.Hour)
+	if _, err := links.Verify(linkQuery(t, link)); !errors.Is(err, swf.ErrLinkExpired) {
+		t.Errorf("Expected ErrLinkExpired, got %v", err)
+	}
+
+	// Invalid builds
+	if _, err := links.Build("doc-1", wf, "submit", "jane", swf.ApprovalActionApprove); !errors.Is(err, swf.ErrStepNotCurrent) {
+		t.Errorf("Expected ErrStepNotCurrent for a step which is not current, got %v", err)
+	}
+
+	if _, err := links.Build("doc-1", wf, "approve", "jane", "delete"); err == nil {
+		t.Error("Expected error for invalid action")
+	}
+
+	if _, err := (&swf.ApprovalLinks{}).Build("doc-1", wf, "approve", "jane", swf.ApprovalActionApprove); err == nil {
+		t.Error("Expected error without secret")
+	}
+}
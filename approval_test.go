@@ -0,0 +1,341 @@
+package swf_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestVoteQuorum(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(swf.NewStepTypeRegistry())
+
+	approval := swf.NewStep("board_approval")
+	approval.Type = swf.StepTypeApproval
+	approval.Approval = &swf.ApprovalRule{
+		Approvers: []string{"alice", "bob", "carol"},
+		Quorum:    2,
+	}
+
+	wf.AddStep(swf.NewStep("draft"))
+	wf.AddStep(approval)
+	wf.AddStep(swf.NewStep("publish"))
+	wf.SetCurrentStep("board_approval")
+
+	tally, err := wf.Vote("board_approval", "alice", swf.ApprovalActionApprove, "Looks good")
+	if err != nil {
+		t.Fatalf("Vote failed: %v", err)
+	}
+
+	if tally.Approvals != 1 || tally.Required != 2 || tally.Approved {
+		t.Errorf("Unexpected tally %+v", tally)
+	}
+
+	if tally.String() != "1/2 approvals, 0 rejections, pending: [bob carol]" {
+		t.Errorf("Unexpected tally string %s", tally)
+	}
+
+	progress := wf.GetStepProgress("board_approval")
+	if progress == nil || progress.Done != 1 || progress.Total != 2 {
+		t.Errorf("Expected progress 1/2, got %+v", progress)
+	}
+
+	if !strings.Contains(wf.Visualize(), "1/2 approvals") {
+		t.Errorf("Expected tally in tooltip, got %s", wf.Visualize())
+	}
+
+	if err := wf.AdvanceCurrentStep(); !errors.Is(err, swf.ErrStepIncomplete) {
+		t.Errorf("Expected ErrStepIncomplete before quorum, got %v", err)
+	}
+
+	tally, err = wf.Vote("board_approval", "bob", swf.ApprovalActionApprove, "")
+	if err != nil {
+		t.Fatalf("Vote failed: %v", err)
+	}
+
+	if !tally.Approved {
+		t.Errorf("Expected approved tally, got %+v", tally)
+	}
+
+	if wf.GetState().CurrentStepName != "publish" {
+		t.Errorf("Expected current step publish, got %s", wf.GetState().CurrentStepName)
+	}
+
+	if wf.GetStepMeta("board_approval", "approved_by") != "bob" {
+		t.Errorf("Expected approved_by bob, got %v", wf.GetStepMeta("board_approval", "approved_by"))
+	}
+
+	votes := wf.GetState().StepDetails["board_approval"].Votes
+	if len(votes) != 2 || votes[0].Actor != "alice" || votes[0].Comment != "Looks good" {
+		t.Errorf("Unexpected votes %+v", votes)
+	}
+
+	if _, err := wf.Vote("board_approval", "carol", swf.ApprovalActionApprove, ""); !errors.Is(err, swf.ErrStepNotCurrent) {
+		t.Errorf("Expected ErrStepNotCurrent after approval, got %v", err)
+	}
+}
+
+func TestVoteAllApprovers(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(swf.NewStepTypeRegistry())
+
+	approval := swf.NewStep("board_approval")
+	approval.Type = swf.StepTypeApproval
+	approval.Approval = &swf.ApprovalRule{
+		Approvers: []string{"legal1", "legal2"},
+	}
+
+	wf.AddStep(swf.NewStep("draft"))
+	wf.AddStep(approval)
+	wf.AddStep(swf.NewStep("publish"))
+	wf.SetCurrentStep("board_approval")
+
+	tally, _ := wf.Vote("board_approval", "legal1", swf.ApprovalActionApprove, "")
+	if tally.Required != 2 || tally.Approved {
+		t.Errorf("Expected all approvers required, got %+v", tally)
+	}
+
+	wf.Vote("board_approval", "legal2", swf.ApprovalActionApprove, "")
+
+	if wf.GetState().CurrentStepName != "publish" {
+		t.Errorf("Expected current step publish, got %s", wf.GetState().CurrentStepName)
+	}
+}
+
+func TestVoteVeto(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(swf.NewStepTypeRegistry())
+
+	approval := swf.NewStep("board_approval")
+	approval.Type = swf.StepTypeApproval
+	approval.Approval = &swf.ApprovalRule{
+		Approvers: []string{"alice", "bob", "carol"},
+		Quorum:    2,
+	}
+
+	wf.AddStep(swf.NewStep("draft"))
+	wf.AddStep(approval)
+	wf.AddStep(swf.NewStep("publish"))
+	wf.SetCurrentStep("board_approval")
+
+	wf.Vote("board_approval", "alice", swf.ApprovalActionApprove, "")
+
+	tally, err := wf.Vote("board_approval", "bob", swf.ApprovalActionReject, "Missing budget")
+	if err != nil {
+		t.Fatalf("Vote failed: %v", err)
+	}
+
+	if !tally.Rejected {
+		t.Errorf("Expected rejected tally, got %+v", tally)
+	}
+
+	if wf.GetState().CurrentStepName != "draft" {
+		t.Errorf("Expected current step draft after veto, got %s", wf.GetState().CurrentStepName)
+	}
+
+	if wf.GetStepMeta("board_approval", "rejected_by") != "bob" {
+		t.Errorf("Expected rejected_by bob, got %v", wf.GetStepMeta("board_approval", "rejected_by"))
+	}
+
+	if wf.GetStepProgress("board_approval") != nil {
+		t.Errorf("Expected progress reset after veto, got %+v", wf.GetStepProgress("board_approval"))
+	}
+
+	// Reaching the step again starts a new vote
+	wf.SetCurrentStep("board_approval")
+
+	tally, _ = wf.GetApprovalTally("board_approval")
+	if tally.Approvals != 0 || tally.Rejections != 0 || len(tally.Pending) != 3 {
+		t.Errorf("Expected a new vote, got %+v", tally)
+	}
+}
+
+func TestVoteQuorumOutOfReach(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(swf.NewStepTypeRegistry())
+
+	approval := swf.NewStep("board_approval")
+	approval.Type = swf.StepTypeApproval
+	approval.Approval = &swf.ApprovalRule{
+		Approvers: []string{"alice", "bob", "carol"},
+		Quorum:    3,
+		Vetoes:    2,
+	}
+
+	wf.AddStep(swf.NewStep("draft"))
+	wf.AddStep(approval)
+	wf.AddStep(swf.NewStep("publish"))
+	wf.SetCurrentStep("board_approval")
+
+	tally, _ := wf.Vote("board_approval", "alice", swf.ApprovalActionReject, "")
+	if !tally.Rejected {
+		t.Errorf("Expected rejection as the quorum of 3 is out of reach, got %+v", tally)
+	}
+
+	if wf.GetState().CurrentStepName != "draft" {
+		t.Errorf("Expected current step draft, got %s", wf.GetState().CurrentStepName)
+	}
+}
+
+func TestVoteReplaced(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(swf.NewStepTypeRegistry())
+
+	approval := swf.NewStep("board_approval")
+	approval.Type = swf.StepTypeApproval
+	approval.Approval = &swf.ApprovalRule{
+		Approvers: []string{"alice", "bob"},
+		Vetoes:    2,
+		Quorum:    1,
+	}
+
+	wf.AddStep(swf.NewStep("draft"))
+	wf.AddStep(approval)
+	wf.AddStep(swf.NewStep("publish"))
+	wf.SetCurrentStep("board_approval")
+
+	wf.Vote("board_approval", "alice", swf.ApprovalActionReject, "")
+	tally, _ := wf.Vote("board_approval", "alice", swf.ApprovalActionApprove, "Changed my mind")
+
+	if tally.Approvals != 1 || tally.Rejections != 0 {
+		t.Errorf("Expected the vote to be replaced, got %+v", tally)
+	}
+
+	if wf.GetState().CurrentStepName != "publish" {
+		t.Errorf("Expected current step publish, got %s", wf.GetState().CurrentStepName)
+	}
+}
+
+func TestVoteNotApprover(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(swf.NewStepTypeRegistry())
+
+	approval := swf.NewStep("board_approval")
+	approval.Type = swf.StepTypeApproval
+	approval.Approval = &swf.ApprovalRule{
+		Approvers: []string{"alice"},
+	}
+
+	wf.AddStep(swf.NewStep("draft"))
+	wf.AddStep(approval)
+	wf.AddStep(swf.NewStep("publish"))
+	wf.SetCurrentStep("board_approval")
+
+	if _, err := wf.Vote("board_approval", "mallory", swf.ApprovalActionApprove, ""); !errors.Is(err, swf.ErrNotApprover) {
+		t.Errorf("Expected ErrNotApprover, got %v", err)
+	}
+
+	if _, err := wf.Vote("board_approval", "alice", "maybe", ""); err == nil {
+		t.Error("Expected error for an invalid decision")
+	}
+
+	if _, err := wf.Vote("draft", "alice", swf.ApprovalActionApprove, ""); !errors.Is(err, swf.ErrNotApprovalStep) {
+		t.Errorf("Expected ErrNotApprovalStep, got %v", err)
+	}
+
+	wf.SetCurrentStep("publish")
+	if _, err := wf.Vote("board_approval", "alice", swf.ApprovalActionApprove, ""); !errors.Is(err, swf.ErrStepNotCurrent) {
+		t.Errorf("Expected ErrStepNotCurrent, got %v", err)
+	}
+}
+
+func TestVoteNotApprovalStep(t *testing.T) {
+	wf := swf.NewWorkflow()
+
+	// Without the built-in step types, approval is a label and the step a normal step
+	approval := swf.NewStep("board_approval")
+	approval.Type = swf.StepTypeApproval
+	approval.Approval = &swf.ApprovalRule{Approvers: []string{"alice", "bob"}}
+
+	wf.AddStep(approval)
+	wf.AddStep(swf.NewStep("publish"))
+
+	if _, err := wf.Vote("board_approval", "alice", swf.ApprovalActionApprove, ""); !errors.Is(err, swf.ErrNotApprovalStep) {
+		t.Errorf("Expected ErrNotApprovalStep, got %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "board_approval" {
+		t.Errorf("Expected the step to stay current, got %s", wf.GetState().CurrentStepName)
+	}
+}
+
+func TestVoteRejectFirstStep(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(swf.NewStepTypeRegistry())
+
+	approval := swf.NewStep("board_approval")
+	approval.Type = swf.StepTypeApproval
+	approval.Approval = &swf.ApprovalRule{Approvers: []string{"alice", "bob"}}
+
+	wf.AddStep(approval)
+	wf.AddStep(swf.NewStep("publish"))
+
+	if _, err := wf.Vote("board_approval", "alice", swf.ApprovalActionReject, ""); !errors.Is(err, swf.ErrNoPreviousStep) {
+		t.Errorf("Expected ErrNoPreviousStep, got %v", err)
+	}
+
+	details := wf.GetState().StepDetails["board_approval"]
+	if len(details.Votes) != 0 || details.Meta["rejected_by"] != nil || details.Progress != nil {
+		t.Errorf("Expected nothing to be recorded, got %+v", details)
+	}
+}
+
+func TestVoteAgainAfterRejection(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(swf.NewStepTypeRegistry())
+
+	approval := swf.NewStep("board_approval")
+	approval.Type = swf.StepTypeApproval
+	approval.Approval = &swf.ApprovalRule{Approvers: []string{"alice", "bob"}}
+
+	wf.AddStep(swf.NewStep("draft"))
+	wf.AddStep(approval)
+	wf.AddStep(swf.NewStep("publish"))
+	wf.SetCurrentStep("board_approval")
+
+	wf.Vote("board_approval", "alice", swf.ApprovalActionApprove, "")
+	wf.Vote("board_approval", "bob", swf.ApprovalActionApprove, "")
+
+	// Rejected back from the next step, the step is voted on again
+	if err := wf.RejectCurrentStep(); err != nil {
+		t.Fatalf("RejectCurrentStep failed: %v", err)
+	}
+
+	if progress := wf.GetStepProgress("board_approval"); progress != nil {
+		t.Errorf("Expected the progress of the previous vote to be reset, got %+v", progress)
+	}
+
+	if percents := wf.GetProgress().Percents; percents > 34 {
+		t.Errorf("Expected only draft to count as done, got %v%%", percents)
+	}
+
+	if wf.GetStepMeta("board_approval", "approved_by") != nil {
+		t.Errorf("Expected approved_by to be cleared, got %v", wf.GetStepMeta("board_approval", "approved_by"))
+	}
+
+	tally, err := wf.Vote("board_approval", "alice", swf.ApprovalActionApprove, "")
+	if err != nil {
+		t.Fatalf("Vote failed: %v", err)
+	}
+
+	if tally.Approvals != 1 || tally.Approved {
+		t.Errorf("Expected a new vote, got %+v", tally)
+	}
+
+	if progress := wf.GetStepProgress("board_approval"); progress == nil || progress.Done != 1 || progress.Total != 2 {
+		t.Errorf("Expected progress 1/2, got %+v", progress)
+	}
+
+	// A veto, then a new activation, clears rejected_by
+	wf.Vote("board_approval", "bob", swf.ApprovalActionReject, "")
+	if wf.GetStepMeta("board_approval", "rejected_by") != "bob" {
+		t.Errorf("Expected rejected_by bob, got %v", wf.GetStepMeta("board_approval", "rejected_by"))
+	}
+
+	wf.AdvanceCurrentStep()
+	if wf.GetStepMeta("board_approval", "rejected_by") != nil {
+		t.Errorf("Expected rejected_by to be cleared, got %v", wf.GetStepMeta("board_approval", "rejected_by"))
+	}
+}
@@ -0,0 +1,130 @@
+package swf
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AutomatedHandler performs the work of an automated step, e.g. generating a PDF
+// or calling an ERP. Returning an error marks the attempt as failed.
+type AutomatedHandler func(w *Workflow, step *Step) error
+
+// AutomatedStepType runs the handler registered for an automated step as soon
+// as the step becomes current, and completes the step when the handler succeeds
+//
+//	automated := swf.NewAutomatedStepType()
+//	automated.Retries = 2
+//	automated.Handle("generate_pdf", func(w *swf.Workflow, step *swf.Step) error {
+//		return pdf.Generate(w.GetStepMeta("invoice", "number"))
+//	})
+//	swf.DefaultStepTypes.Register(swf.StepTypeAutomated, automated)
+type AutomatedStepType struct {
+	// Retries is the number of retries after a failed attempt
+	Retries int
+
+	// RetryDelay is the delay between two attempts. The attempts run within
+	// the transition entering the step, e.g. within an HTTP request: keep the
+	// delay short, and retry later failures with EnterCurrentStep from a job.
+	RetryDelay time.Duration
+
+	mu       sync.RWMutex
+	handlers map[string]AutomatedHandler
+}
+
+// NewAutomatedStepType creates a new automated step type, without handlers nor retries
+func NewAutomatedStepType() *AutomatedStepType {
+	return &AutomatedStepType{handlers: map[string]AutomatedHandler{}}
+}
+
+// Handle registers the handler of an automated step, replacing any existing one
+func (t *AutomatedStepType) Handle(stepName string, handler AutomatedHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.handlers == nil {
+		t.handlers = map[string]AutomatedHandler{}
+	}
+	t.handlers[stepName] = handler
+}
+
+// handler returns the handler of an automated step, or nil if not registered
+func (t *AutomatedStepType) handler(stepName string) AutomatedHandler {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.handlers[stepName]
+}
+
+// OnEnter runs the handler of the step, and advances the workflow on success
+//
+// Business logic:
+// 1. Find the handler of the step, failing with ErrNoHandler if not registered
+// 2. Run the handler up to 1 + Retries times, waiting RetryDelay between attempts
+// 3. Record the attempts and the last error in the step details
+// 4. On success, advance the workflow if the step is still current
+// 5. On failure, return ErrStepFailed: the step stays current and the state should be saved
+func (t *AutomatedStepType) OnEnter(w *Workflow, step *Step) error {
+	details := w.state.StepDetails[step.Name]
+	if details == nil {
+		return fmt.Errorf("%w: %s", ErrStepNotFound, step.Name)
+	}
+
+	handler := t.handler(step.Name)
+	if handler == nil {
+		details.Error = ErrNoHandler.Error()
+		return fmt.Errorf("%w: %s: %w", ErrStepFailed, step.Name, ErrNoHandler)
+	}
+
+	var err error
+	for attempt := 0; attempt <= t.Retries; attempt++ {
+		if attempt > 0 && t.RetryDelay > 0 {
+			time.Sleep(t.RetryDelay)
+		}
+
+		details.Attempts++
+		if err = runHandler(handler, w, step); err == nil {
+			break
+		}
+		details.Error = err.Error()
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %s failed after %d attempts: %w", ErrStepFailed, step.Name, t.Retries+1, err)
+	}
+
+	details.Error = ""
+
+	if !w.IsStepCurrent(step) {
+		return nil
+	}
+
+	return w.AdvanceCurrentStep()
+}
+
+// ValidateComplete always allows the completion, e.g. to skip a failing step manually
+func (t *AutomatedStepType) ValidateComplete(w *Workflow, step *Step) error {
+	return nil
+}
+
+// RenderHints renders the step as a component, with the last error in the tooltip
+func (t *AutomatedStepType) RenderHints(w *Workflow, step *Step) RenderHints {
+	hints := RenderHints{Shape: "component"}
+
+	if details := w.state.StepDetails[step.Name]; details != nil && details.Error != "" {
+		hints.Tooltip = fmt.Sprintf("Failed after %d attempts: %s", details.Attempts, details.Error)
+	}
+
+	return hints
+}
+
+// runHandler runs a handler, converting a panic into an error
+func runHandler(handler AutomatedHandler, w *Workflow, step *Step) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("handler panicked: %v", recovered)
+		}
+	}()
+
+	return handler(w, step)
+}
@@ -0,0 +1,250 @@
+package swf_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestAutomatedStepSuccess(t *testing.T) {
+	automated := swf.NewAutomatedStepType()
+
+	runs := 0
+	automated.Handle("generate_pdf", func(w *swf.Workflow, step *swf.Step) error {
+		runs++
+		return nil
+	})
+
+	registry := swf.NewStepTypeRegistry()
+	registry.Register(swf.StepTypeAutomated, automated)
+
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(registry)
+
+	generate := swf.NewStep("generate_pdf")
+	generate.Type = swf.StepTypeAutomated
+
+	wf.AddStep(swf.NewStep("invoice"))
+	wf.AddStep(generate)
+	wf.AddStep(swf.NewStep("send"))
+
+	if err := wf.AdvanceCurrentStep(); err != nil {
+		t.Fatalf("AdvanceCurrentStep failed: %v", err)
+	}
+
+	if runs != 1 {
+		t.Errorf("Expected 1 run, got %d", runs)
+	}
+
+	if wf.GetState().CurrentStepName != "send" {
+		t.Errorf("Expected current step send, got %s", wf.GetState().CurrentStepName)
+	}
+
+	if !wf.IsStepComplete("generate_pdf") {
+		t.Error("Expected generate_pdf to be completed")
+	}
+
+	details := wf.GetState().StepDetails["generate_pdf"]
+	if details.Attempts != 1 || details.Error != "" {
+		t.Errorf("Unexpected details %+v", details)
+	}
+}
+
+func TestAutomatedStepRetries(t *testing.T) {
+	automated := swf.NewAutomatedStepType()
+	automated.Retries = 2
+
+	runs := 0
+	automated.Handle("generate_pdf", func(w *swf.Workflow, step *swf.Step) error {
+		runs++
+		if runs < 3 {
+			return errors.New("ERP unavailable")
+		}
+		return nil
+	})
+
+	registry := swf.NewStepTypeRegistry()
+	registry.Register(swf.StepTypeAutomated, automated)
+
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(registry)
+
+	generate := swf.NewStep("generate_pdf")
+	generate.Type = swf.StepTypeAutomated
+
+	wf.AddStep(swf.NewStep("invoice"))
+	wf.AddStep(generate)
+	wf.AddStep(swf.NewStep("send"))
+
+	if err := wf.AdvanceCurrentStep(); err != nil {
+		t.Fatalf("AdvanceCurrentStep failed: %v", err)
+	}
+
+	if runs != 3 || wf.GetState().CurrentStepName != "send" {
+		t.Errorf("Expected success on the third attempt, got %d runs at %s", runs, wf.GetState().CurrentStepName)
+	}
+
+	details := wf.GetState().StepDetails["generate_pdf"]
+	if details.Attempts != 3 || details.Error != "" {
+		t.Errorf("Expected 3 attempts and the error cleared, got %+v", details)
+	}
+}
+
+func TestAutomatedStepFailure(t *testing.T) {
+	automated := swf.NewAutomatedStepType()
+	automated.Retries = 1
+
+	failing := true
+	automated.Handle("generate_pdf", func(w *swf.Workflow, step *swf.Step) error {
+		if failing {
+			return errors.New("ERP unavailable")
+		}
+		return nil
+	})
+
+	registry := swf.NewStepTypeRegistry()
+	registry.Register(swf.StepTypeAutomated, automated)
+
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(registry)
+
+	generate := swf.NewStep("generate_pdf")
+	generate.Type = swf.StepTypeAutomated
+
+	wf.AddStep(swf.NewStep("invoice"))
+	wf.AddStep(generate)
+	wf.AddStep(swf.NewStep("send"))
+
+	err := wf.AdvanceCurrentStep()
+	if !errors.Is(err, swf.ErrStepFailed) || !strings.Contains(err.Error(), "ERP unavailable") {
+		t.Fatalf("Expected ErrStepFailed with the handler error, got %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "generate_pdf" {
+		t.Errorf("Expected the failed step to stay current, got %s", wf.GetState().CurrentStepName)
+	}
+
+	details := wf.GetState().StepDetails["generate_pdf"]
+	if details.Attempts != 2 || details.Error != "ERP unavailable" {
+		t.Errorf("Expected 2 attempts with the error recorded, got %+v", details)
+	}
+
+	if !strings.Contains(wf.Visualize(), "Failed after 2 attempts: ERP unavailable") {
+		t.Errorf("Expected the error in the tooltip, got %s", wf.Visualize())
+	}
+
+	// Retry once the cause is fixed
+	failing = false
+	if err := wf.EnterCurrentStep(); err != nil {
+		t.Fatalf("EnterCurrentStep failed: %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "send" {
+		t.Errorf("Expected current step send after the retry, got %s", wf.GetState().CurrentStepName)
+	}
+}
+
+func TestAutomatedStepPanic(t *testing.T) {
+	automated := swf.NewAutomatedStepType()
+	automated.Handle("generate_pdf", func(w *swf.Workflow, step *swf.Step) error {
+		panic("out of paper")
+	})
+
+	registry := swf.NewStepTypeRegistry()
+	registry.Register(swf.StepTypeAutomated, automated)
+
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(registry)
+
+	generate := swf.NewStep("generate_pdf")
+	generate.Type = swf.StepTypeAutomated
+
+	wf.AddStep(swf.NewStep("invoice"))
+	wf.AddStep(generate)
+	wf.AddStep(swf.NewStep("send"))
+
+	if err := wf.AdvanceCurrentStep(); err == nil || !strings.Contains(err.Error(), "out of paper") {
+		t.Errorf("Expected the panic as an error, got %v", err)
+	}
+
+	if wf.GetState().StepDetails["generate_pdf"].Error == "" {
+		t.Error("Expected the panic to be recorded")
+	}
+}
+
+func TestAutomatedStepNoHandler(t *testing.T) {
+	automated := swf.NewAutomatedStepType()
+	registry := swf.NewStepTypeRegistry()
+	registry.Register(swf.StepTypeAutomated, automated)
+
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(registry)
+
+	generate := swf.NewStep("generate_pdf")
+	generate.Type = swf.StepTypeAutomated
+
+	wf.AddStep(swf.NewStep("invoice"))
+	wf.AddStep(generate)
+	wf.AddStep(swf.NewStep("send"))
+
+	err := wf.AdvanceCurrentStep()
+	if !errors.Is(err, swf.ErrStepFailed) || !errors.Is(err, swf.ErrNoHandler) {
+		t.Errorf("Expected ErrStepFailed and ErrNoHandler, got %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "generate_pdf" {
+		t.Errorf("Expected the step to stay current, got %s", wf.GetState().CurrentStepName)
+	}
+}
+
+func TestAutomatedStepReject(t *testing.T) {
+	automated := swf.NewAutomatedStepType()
+
+	runs := 0
+	automated.Handle("generate_pdf", func(w *swf.Workflow, step *swf.Step) error {
+		runs++
+		return nil
+	})
+
+	registry := swf.NewStepTypeRegistry()
+	registry.Register(swf.StepTypeAutomated, automated)
+
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(registry)
+
+	generate := swf.NewStep("generate_pdf")
+	generate.Type = swf.StepTypeAutomated
+
+	wf.AddStep(swf.NewStep("invoice"))
+	wf.AddStep(generate)
+	wf.AddStep(swf.NewStep("send"))
+
+	wf.AdvanceCurrentStep()
+
+	// Rejecting send goes back to invoice, without running the handler
+	if err := wf.RejectCurrentStep(); err != nil {
+		t.Fatalf("RejectCurrentStep failed: %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "invoice" || runs != 1 {
+		t.Errorf("Expected invoice current after 1 run, got %s after %d runs", wf.GetState().CurrentStepName, runs)
+	}
+
+	history := wf.GetState().History
+	if strings.Join(history, " ") != "invoice generate_pdf send invoice" {
+		t.Errorf("Unexpected history %v", history)
+	}
+
+	// The first step is automated: there is no step to go back to
+	wf = swf.NewWorkflow()
+	wf.SetStepTypes(registry)
+	wf.AddStep(generate)
+	wf.AddStep(swf.NewStep("send"))
+	wf.EnterCurrentStep()
+
+	if err := wf.RejectCurrentStep(); !errors.Is(err, swf.ErrNoPreviousStep) {
+		t.Errorf("Expected ErrNoPreviousStep, got %v", err)
+	}
+}
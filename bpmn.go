@@ -0,0 +1,509 @@
+package swf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// BPMN 2.0 namespaces used when exporting
+const (
+	bpmnNamespaceModel  = "http://www.omg.org/spec/BPMN/20100524/MODEL"
+	bpmnNamespaceDI     = "http://www.omg.org/spec/BPMN/20100524/DI"
+	bpmnNamespaceDC     = "http://www.omg.org/spec/DD/20100524/DC"
+	bpmnNamespaceDDI    = "http://www.omg.org/spec/DD/20100524/DI"
+	bpmnTargetNamespace = "http://bpmn.io/schema/bpmn"
+)
+
+// BPMN element IDs generated on export
+const (
+	bpmnStartEventID    = "StartEvent_1"
+	bpmnEndEventID      = "EndEvent_1"
+	bpmnProcessID       = "Process_1"
+	bpmnParticipantID   = "Participant_1"
+	bpmnCollaborationID = "Collaboration_1"
+)
+
+// Layout of the exported diagram
+const (
+	bpmnPoolX       = 100
+	bpmnPoolY       = 80
+	bpmnPoolLabel   = 30
+	bpmnLaneHeight  = 120
+	bpmnTaskWidth   = 100
+	bpmnTaskHeight  = 80
+	bpmnTaskSpacing = 150
+	bpmnEventSize   = 36
+)
+
+// bpmnIDRegex matches identifiers usable as BPMN element IDs (XML NCName)
+var bpmnIDRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.\-]*$`)
+
+// bpmnTaskElements are the BPMN elements imported as steps
+var bpmnTaskElements = []string{"task", "userTask", "manualTask"}
+
+// bpmnIgnoredElements are BPMN elements which carry no workflow semantics
+var bpmnIgnoredElements = []string{"documentation", "extensionElements", "incoming", "outgoing"}
+
+type bpmnExportDefinitions struct {
+	XMLName         xml.Name          `xml:"bpmn:definitions"`
+	XmlnsBPMN       string            `xml:"xmlns:bpmn,attr"`
+	XmlnsBPMNDI     string            `xml:"xmlns:bpmndi,attr"`
+	XmlnsDC         string            `xml:"xmlns:dc,attr"`
+	XmlnsDI         string            `xml:"xmlns:di,attr"`
+	ID              string            `xml:"id,attr"`
+	TargetNamespace string            `xml:"targetNamespace,attr"`
+	Collaboration   bpmnExportCollab  `xml:"bpmn:collaboration"`
+	Process         bpmnExportProcess `xml:"bpmn:process"`
+	Diagram         bpmnExportDiagram `xml:"bpmndi:BPMNDiagram"`
+}
+
+type bpmnExportCollab struct {
+	ID          string                `xml:"id,attr"`
+	Participant bpmnExportParticipant `xml:"bpmn:participant"`
+}
+
+type bpmnExportParticipant struct {
+	ID         string `xml:"id,attr"`
+	Name       string `xml:"name,attr"`
+	ProcessRef string `xml:"processRef,attr"`
+}
+
+type bpmnExportProcess struct {
+	ID            string              `xml:"id,attr"`
+	IsExecutable  bool                `xml:"isExecutable,attr"`
+	LaneSet       bpmnExportLaneSet   `xml:"bpmn:laneSet"`
+	StartEvent    bpmnExportNode      `xml:"bpmn:startEvent"`
+	Tasks         []bpmnExportNode    `xml:"bpmn:userTask"`
+	EndEvent      bpmnExportNode      `xml:"bpmn:endEvent"`
+	SequenceFlows []bpmnExportSeqFlow `xml:"bpmn:sequenceFlow"`
+}
+
+type bpmnExportLaneSet struct {
+	ID    string           `xml:"id,attr"`
+	Lanes []bpmnExportLane `xml:"bpmn:lane"`
+}
+
+type bpmnExportLane struct {
+	ID          string   `xml:"id,attr"`
+	Name        string   `xml:"name,attr"`
+	FlowNodeRef []string `xml:"bpmn:flowNodeRef"`
+}
+
+type bpmnExportNode struct {
+	ID            string `xml:"id,attr"`
+	Name          string `xml:"name,attr,omitempty"`
+	Documentation string `xml:"bpmn:documentation,omitempty"`
+}
+
+type bpmnExportSeqFlow struct {
+	ID        string `xml:"id,attr"`
+	SourceRef string `xml:"sourceRef,attr"`
+	TargetRef string `xml:"targetRef,attr"`
+}
+
+type bpmnExportDiagram struct {
+	ID    string          `xml:"id,attr"`
+	Plane bpmnExportPlane `xml:"bpmndi:BPMNPlane"`
+}
+
+type bpmnExportPlane struct {
+	ID          string            `xml:"id,attr"`
+	BPMNElement string            `xml:"bpmnElement,attr"`
+	Shapes      []bpmnExportShape `xml:"bpmndi:BPMNShape"`
+	Edges       []bpmnExportEdge  `xml:"bpmndi:BPMNEdge"`
+}
+
+type bpmnExportShape struct {
+	ID           string           `xml:"id,attr"`
+	BPMNElement  string           `xml:"bpmnElement,attr"`
+	IsHorizontal *bool            `xml:"isHorizontal,attr,omitempty"`
+	Bounds       bpmnExportBounds `xml:"dc:Bounds"`
+}
+
+type bpmnExportBounds struct {
+	X      int `xml:"x,attr"`
+	Y      int `xml:"y,attr"`
+	Width  int `xml:"width,attr"`
+	Height int `xml:"height,attr"`
+}
+
+type bpmnExportEdge struct {
+	ID          string               `xml:"id,attr"`
+	BPMNElement string               `xml:"bpmnElement,attr"`
+	Waypoints   []bpmnExportWaypoint `xml:"di:waypoint"`
+}
+
+type bpmnExportWaypoint struct {
+	X int `xml:"x,attr"`
+	Y int `xml:"y,attr"`
+}
+
+// ToBPMN exports the workflow definition as BPMN 2.0 XML
+//
+// Business logic:
+// 1. Each step becomes a user task, in the order of the steps
+// 2. Tasks are connected with sequence flows, from a start event to an end event
+// 3. Each distinct Responsible becomes a lane, containing its tasks
+// 4. A diagram (BPMN DI) is generated, so that modelers can display the process
+func (w *Workflow) ToBPMN() (string, error) {
+	if len(w.steps) == 0 {
+		return "", fmt.Errorf("cannot export an empty workflow to BPMN")
+	}
+
+	for _, step := range w.steps {
+		if !bpmnIDRegex.MatchString(step.Name) {
+			return "", fmt.Errorf("step name is not a valid BPMN id: %s", step.Name)
+		}
+	}
+
+	// Lanes, in order of first appearance
+	laneIndexes := map[string]int{}
+	lanes := []bpmnExportLane{}
+	for _, step := range w.steps {
+		index, exists := laneIndexes[step.Responsible]
+		if !exists {
+			index = len(lanes)
+			laneIndexes[step.Responsible] = index
+			lanes = append(lanes, bpmnExportLane{
+				ID:   fmt.Sprintf("Lane_%d", index+1),
+				Name: step.Responsible,
+			})
+		}
+		lanes[index].FlowNodeRef = append(lanes[index].FlowNodeRef, step.Name)
+	}
+
+	// Start and end events are placed in the lanes of the first and last steps
+	firstLane := laneIndexes[w.steps[0].Responsible]
+	lastLane := laneIndexes[w.steps[len(w.steps)-1].Responsible]
+	lanes[firstLane].FlowNodeRef = append([]string{bpmnStartEventID}, lanes[firstLane].FlowNodeRef...)
+	lanes[lastLane].FlowNodeRef = append(lanes[lastLane].FlowNodeRef, bpmnEndEventID)
+
+	// Flow nodes, in order
+	nodeIDs := []string{bpmnStartEventID}
+	tasks := make([]bpmnExportNode, 0, len(w.steps))
+	for _, step := range w.steps {
+		nodeIDs = append(nodeIDs, step.Name)
+		tasks = append(tasks, bpmnExportNode{
+			ID:            step.Name,
+			Name:          step.Title,
+			Documentation: step.Description,
+		})
+	}
+	nodeIDs = append(nodeIDs, bpmnEndEventID)
+
+	flows := make([]bpmnExportSeqFlow, 0, len(nodeIDs)-1)
+	for i := 1; i < len(nodeIDs); i++ {
+		flows = append(flows, bpmnExportSeqFlow{
+			ID:        fmt.Sprintf("Flow_%d", i),
+			SourceRef: nodeIDs[i-1],
+			TargetRef: nodeIDs[i],
+		})
+	}
+
+	definitions := bpmnExportDefinitions{
+		XmlnsBPMN:       bpmnNamespaceModel,
+		XmlnsBPMNDI:     bpmnNamespaceDI,
+		XmlnsDC:         bpmnNamespaceDC,
+		XmlnsDI:         bpmnNamespaceDDI,
+		ID:              "Definitions_1",
+		TargetNamespace: bpmnTargetNamespace,
+		Collaboration: bpmnExportCollab{
+			ID: bpmnCollaborationID,
+			Participant: bpmnExportParticipant{
+				ID:         bpmnParticipantID,
+				Name:       "Workflow",
+				ProcessRef: bpmnProcessID,
+			},
+		},
+		Process: bpmnExportProcess{
+			ID:            bpmnProcessID,
+			IsExecutable:  false,
+			LaneSet:       bpmnExportLaneSet{ID: "LaneSet_1", Lanes: lanes},
+			StartEvent:    bpmnExportNode{ID: bpmnStartEventID},
+			Tasks:         tasks,
+			EndEvent:      bpmnExportNode{ID: bpmnEndEventID},
+			SequenceFlows: flows,
+		},
+		Diagram: w.bpmnDiagram(laneIndexes, lanes, nodeIDs, flows),
+	}
+
+	data, err := xml.MarshalIndent(definitions, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return xml.Header + string(data), nil
+}
+
+// bpmnDiagram lays out the exported process left-to-right, with one lane per row
+func (w *Workflow) bpmnDiagram(laneIndexes map[string]int, lanes []bpmnExportLane, nodeIDs []string, flows []bpmnExportSeqFlow) bpmnExportDiagram {
+	horizontal := true
+	poolWidth := bpmnPoolLabel + (len(nodeIDs)+1)*bpmnTaskSpacing
+	laneX := bpmnPoolX + bpmnPoolLabel
+
+	shapes := []bpmnExportShape{{
+		ID:           bpmnParticipantID + "_di",
+		BPMNElement:  bpmnParticipantID,
+		IsHorizontal: &horizontal,
+		Bounds: bpmnExportBounds{
+			X:      bpmnPoolX,
+			Y:      bpmnPoolY,
+			Width:  poolWidth,
+			Height: len(lanes) * bpmnLaneHeight,
+		},
+	}}
+
+	for i, lane := range lanes {
+		shapes = append(shapes, bpmnExportShape{
+			ID:           lane.ID + "_di",
+			BPMNElement:  lane.ID,
+			IsHorizontal: &horizontal,
+			Bounds: bpmnExportBounds{
+				X:      laneX,
+				Y:      bpmnPoolY + i*bpmnLaneHeight,
+				Width:  poolWidth - bpmnPoolLabel,
+				Height: bpmnLaneHeight,
+			},
+		})
+	}
+
+	// Node bounds, indexed by node ID
+	bounds := map[string]bpmnExportBounds{}
+	for i, nodeID := range nodeIDs {
+		var lane int
+		switch {
+		case i == 0:
+			lane = laneIndexes[w.steps[0].Responsible]
+		case i == len(nodeIDs)-1:
+			lane = laneIndexes[w.steps[len(w.steps)-1].Responsible]
+		default:
+			lane = laneIndexes[w.steps[i-1].Responsible]
+		}
+
+		laneMiddle := bpmnPoolY + lane*bpmnLaneHeight + bpmnLaneHeight/2
+		centerX := laneX + bpmnTaskSpacing/2 + i*bpmnTaskSpacing
+
+		width, height := bpmnTaskWidth, bpmnTaskHeight
+		if i == 0 || i == len(nodeIDs)-1 {
+			width, height = bpmnEventSize, bpmnEventSize
+		}
+
+		bounds[nodeID] = bpmnExportBounds{
+			X:      centerX - width/2,
+			Y:      laneMiddle - height/2,
+			Width:  width,
+			Height: height,
+		}
+
+		shapes = append(shapes, bpmnExportShape{
+			ID:          nodeID + "_di",
+			BPMNElement: nodeID,
+			Bounds:      bounds[nodeID],
+		})
+	}
+
+	edges := make([]bpmnExportEdge, 0, len(flows))
+	for _, flow := range flows {
+		source := bounds[flow.SourceRef]
+		target := bounds[flow.TargetRef]
+
+		sourceX := source.X + source.Width
+		sourceY := source.Y + source.Height/2
+		targetX := target.X
+		targetY := target.Y + target.Height/2
+
+		waypoints := []bpmnExportWaypoint{{X: sourceX, Y: sourceY}}
+		if sourceY != targetY {
+			middleX := (sourceX + targetX) / 2
+			waypoints = append(waypoints,
+				bpmnExportWaypoint{X: middleX, Y: sourceY},
+				bpmnExportWaypoint{X: middleX, Y: targetY},
+			)
+		}
+		waypoints = append(waypoints, bpmnExportWaypoint{X: targetX, Y: targetY})
+
+		edges = append(edges, bpmnExportEdge{
+			ID:          flow.ID + "_di",
+			BPMNElement: flow.ID,
+			Waypoints:   waypoints,
+		})
+	}
+
+	return bpmnExportDiagram{
+		ID: "BPMNDiagram_1",
+		Plane: bpmnExportPlane{
+			ID:          "BPMNPlane_1",
+			BPMNElement: bpmnCollaborationID,
+			Shapes:      shapes,
+			Edges:       edges,
+		},
+	}
+}
+
+// bpmnImportDefinitions is the supported subset of BPMN definitions.
+// Tags have no namespace, so any prefix used by the modeler is accepted.
+type bpmnImportDefinitions struct {
+	Processes []bpmnImportProcess `xml:"process"`
+}
+
+type bpmnImportProcess struct {
+	ID       string              `xml:"id,attr"`
+	Elements []bpmnImportElement `xml:",any"`
+}
+
+type bpmnImportElement struct {
+	XMLName       xml.Name
+	ID            string              `xml:"id,attr"`
+	Name          string              `xml:"name,attr"`
+	SourceRef     string              `xml:"sourceRef,attr"`
+	TargetRef     string              `xml:"targetRef,attr"`
+	Documentation []string            `xml:"documentation"`
+	Lanes         []bpmnImportLane    `xml:"lane"`
+	Children      []bpmnImportElement `xml:",any"`
+}
+
+type bpmnImportLane struct {
+	Name        string           `xml:"name,attr"`
+	FlowNodeRef []string         `xml:"flowNodeRef"`
+	ChildLanes  []bpmnImportLane `xml:"childLaneSet>lane"`
+}
+
+// NewWorkflowFromBPMN imports a workflow definition from BPMN 2.0 XML
+//
+// Only the subset produced by ToBPMN is supported: a single process with
+// one start event, tasks (task, userTask, manualTask) connected by sequence
+// flows into a single linear path, an optional end event and lanes.
+// Any other flow element (gateways, sub-processes, boundary events, etc.)
+// results in an error naming the unsupported element.
+//
+// Business logic:
+// 1. Parse the XML and find the process
+// 2. Reject unsupported elements
+// 3. Follow the sequence flows from the start event, creating a step per task
+// 4. Set Responsible of each step from the lane containing its task
+func NewWorkflowFromBPMN(data string) (*Workflow, error) {
+	definitions := bpmnImportDefinitions{}
+	if err := xml.Unmarshal([]byte(data), &definitions); err != nil {
+		return nil, fmt.Errorf("invalid BPMN XML: %w", err)
+	}
+
+	if len(definitions.Processes) != 1 {
+		return nil, fmt.Errorf("BPMN must contain exactly one process, found %d", len(definitions.Processes))
+	}
+
+	process := definitions.Processes[0]
+
+	startEventID := ""
+	hasEndEvent := false
+	tasks := map[string]bpmnImportElement{}
+	outgoing := map[string]string{}
+	incoming := map[string]string{}
+	responsibles := map[string]string{}
+
+	for _, element := range process.Elements {
+		kind := element.XMLName.Local
+
+		switch {
+		case kind == "startEvent":
+			if startEventID != "" {
+				return nil, fmt.Errorf("BPMN process must have exactly one start event")
+			}
+			if err := bpmnCheckEventDefinitions(element); err != nil {
+				return nil, err
+			}
+			startEventID = element.ID
+		case kind == "endEvent":
+			if hasEndEvent {
+				return nil, fmt.Errorf("BPMN process must have at most one end event")
+			}
+			if err := bpmnCheckEventDefinitions(element); err != nil {
+				return nil, err
+			}
+			hasEndEvent = true
+		case lo.Contains(bpmnTaskElements, kind):
+			tasks[element.ID] = element
+		case kind == "sequenceFlow":
+			if _, exists := outgoing[element.SourceRef]; exists {
+				return nil, fmt.Errorf("unsupported BPMN: element %s has more than one outgoing sequence flow", element.SourceRef)
+			}
+			if _, exists := incoming[element.TargetRef]; exists {
+				return nil, fmt.Errorf("unsupported BPMN: element %s has more than one incoming sequence flow", element.TargetRef)
+			}
+			outgoing[element.SourceRef] = element.TargetRef
+			incoming[element.TargetRef] = element.SourceRef
+		case kind == "laneSet":
+			bpmnCollectLanes(element.Lanes, responsibles)
+		case lo.Contains(bpmnIgnoredElements, kind):
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported BPMN element: %s (id: %s)", kind, element.ID)
+		}
+	}
+
+	if startEventID == "" {
+		return nil, fmt.Errorf("BPMN process has no start event")
+	}
+
+	workflow := NewWorkflow()
+	visited := map[string]bool{}
+
+	for current := outgoing[startEventID]; current != ""; current = outgoing[current] {
+		task, isTask := tasks[current]
+		if !isTask {
+			if _, hasNext := outgoing[current]; hasNext {
+				return nil, fmt.Errorf("unsupported BPMN: sequence flow to unknown element %s", current)
+			}
+			break
+		}
+
+		if visited[current] {
+			return nil, fmt.Errorf("unsupported BPMN: loop detected at task %s", current)
+		}
+		visited[current] = true
+
+		step := NewStep(task.ID)
+		step.Title = task.Name
+		step.Description = strings.TrimSpace(strings.Join(task.Documentation, "\n"))
+		if responsible, exists := responsibles[task.ID]; exists && responsible != "" {
+			step.Responsible = responsible
+		}
+
+		if err := workflow.AddStep(step); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(visited) != len(tasks) {
+		for id := range tasks {
+			if !visited[id] {
+				return nil, fmt.Errorf("unsupported BPMN: task %s is not on the path from the start event", id)
+			}
+		}
+	}
+
+	return workflow, nil
+}
+
+// bpmnCheckEventDefinitions rejects typed events (timer, message, etc.)
+func bpmnCheckEventDefinitions(element bpmnImportElement) error {
+	for _, child := range element.Children {
+		if strings.HasSuffix(child.XMLName.Local, "EventDefinition") {
+			return fmt.Errorf("unsupported BPMN element: %s in %s (id: %s)", child.XMLName.Local, element.XMLName.Local, element.ID)
+		}
+	}
+	return nil
+}
+
+// bpmnCollectLanes maps flow node IDs to lane names, including nested lanes
+func bpmnCollectLanes(lanes []bpmnImportLane, responsibles map[string]string) {
+	for _, lane := range lanes {
+		for _, ref := range lane.FlowNodeRef {
+			responsibles[strings.TrimSpace(ref)] = lane.Name
+		}
+		bpmnCollectLanes(lane.ChildLanes, responsibles)
+	}
+}
@@ -0,0 +1,218 @@
+package swf_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestToBPMN(t *testing.T) {
+	wf := swf.NewWorkflow()
+
+	step1 := swf.NewStep("document_review")
+	step1.Title = "Document Review"
+	step1.Description = "Review the submitted document"
+	step1.Responsible = "editor"
+
+	step2 := swf.NewStep("manager_approval")
+	step2.Title = "Manager Approval"
+	step2.Responsible = "manager"
+
+	step3 := swf.NewStep("publish")
+	step3.Title = "Publish"
+	step3.Responsible = "editor"
+
+	wf.AddStep(step1)
+	wf.AddStep(step2)
+	wf.AddStep(step3)
+
+	bpmn, err := wf.ToBPMN()
+	if err != nil {
+		t.Fatalf("ToBPMN failed: %v", err)
+	}
+
+	expected := []string{
+		`<bpmn:definitions xmlns:bpmn="http://www.omg.org/spec/BPMN/20100524/MODEL"`,
+		`<bpmn:userTask id="document_review" name="Document Review">`,
+		`<bpmn:documentation>Review the submitted document</bpmn:documentation>`,
+		`<bpmn:sequenceFlow id="Flow_1" sourceRef="StartEvent_1" targetRef="document_review"></bpmn:sequenceFlow>`,
+		`<bpmn:sequenceFlow id="Flow_4" sourceRef="publish" targetRef="EndEvent_1"></bpmn:sequenceFlow>`,
+		`<bpmn:lane id="Lane_1" name="editor">`,
+		`<bpmn:lane id="Lane_2" name="manager">`,
+		`<bpmndi:BPMNShape id="manager_approval_di" bpmnElement="manager_approval">`,
+	}
+
+	for _, fragment := range expected {
+		if !strings.Contains(bpmn, fragment) {
+			t.Errorf("Expected BPMN to contain %s, got %s", fragment, bpmn)
+		}
+	}
+
+	// Empty workflows cannot be exported
+	if _, err := swf.NewWorkflow().ToBPMN(); err == nil {
+		t.Error("Expected error for empty workflow")
+	}
+
+	// Step names must be valid BPMN ids
+	invalid := swf.NewWorkflow()
+	invalid.AddStep(swf.NewStep("1 invalid"))
+	if _, err := invalid.ToBPMN(); err == nil {
+		t.Error("Expected error for invalid step name")
+	}
+}
+
+func TestBPMNRoundTrip(t *testing.T) {
+	wf := swf.NewWorkflow()
+
+	step1 := swf.NewStep("step1")
+	step1.Title = "First Step"
+	step1.Description = "This is the first step"
+	step1.Responsible = "admin"
+
+	step2 := swf.NewStep("step2")
+	step2.Title = "Second Step"
+	step2.Responsible = "legal"
+
+	wf.AddStep(step1)
+	wf.AddStep(step2)
+
+	bpmn, err := wf.ToBPMN()
+	if err != nil {
+		t.Fatalf("ToBPMN failed: %v", err)
+	}
+
+	imported, err := swf.NewWorkflowFromBPMN(bpmn)
+	if err != nil {
+		t.Fatalf("NewWorkflowFromBPMN failed: %v", err)
+	}
+
+	steps := imported.GetSteps()
+	if len(steps) != 2 {
+		t.Fatalf("Expected 2 steps, got %d", len(steps))
+	}
+
+	for i, step := range wf.GetSteps() {
+		if steps[i].Name != step.Name {
+			t.Errorf("Expected name %s, got %s", step.Name, steps[i].Name)
+		}
+		if steps[i].Title != step.Title {
+			t.Errorf("Expected title %s, got %s", step.Title, steps[i].Title)
+		}
+		if steps[i].Description != step.Description {
+			t.Errorf("Expected description %s, got %s", step.Description, steps[i].Description)
+		}
+		if steps[i].Responsible != step.Responsible {
+			t.Errorf("Expected responsible %s, got %s", step.Responsible, steps[i].Responsible)
+		}
+	}
+
+	if imported.GetState().CurrentStepName != "step1" {
+		t.Errorf("Expected current step 'step1', got %s", imported.GetState().CurrentStepName)
+	}
+}
+
+func TestNewWorkflowFromBPMN(t *testing.T) {
+	// Modeler output, with a different prefix and unordered elements
+	bpmn := `<?xml version="1.0" encoding="UTF-8"?>
+<definitions xmlns="http://www.omg.org/spec/BPMN/20100524/MODEL" id="d1">
+  <process id="p1">
+    <sequenceFlow id="f2" sourceRef="review" targetRef="approve" />
+    <userTask id="approve" name="Approve" />
+    <startEvent id="start" />
+    <task id="review" name="Review">
+      <documentation>Check it</documentation>
+    </task>
+    <sequenceFlow id="f1" sourceRef="start" targetRef="review" />
+  </process>
+</definitions>`
+
+	wf, err := swf.NewWorkflowFromBPMN(bpmn)
+	if err != nil {
+		t.Fatalf("NewWorkflowFromBPMN failed: %v", err)
+	}
+
+	steps := wf.GetSteps()
+	if len(steps) != 2 || steps[0].Name != "review" || steps[1].Name != "approve" {
+		t.Fatalf("Expected steps review, approve, got %v", steps)
+	}
+
+	if steps[0].Description != "Check it" {
+		t.Errorf("Expected description 'Check it', got %s", steps[0].Description)
+	}
+
+	if steps[1].Responsible != "Admin" {
+		t.Errorf("Expected default responsible 'Admin', got %s", steps[1].Responsible)
+	}
+}
+
+func TestNewWorkflowFromBPMNUnsupported(t *testing.T) {
+	tests := []struct {
+		name     string
+		bpmn     string
+		expected string
+	}{
+		{
+			name:     "invalid xml",
+			bpmn:     `<definitions`,
+			expected: "invalid BPMN XML",
+		},
+		{
+			name:     "no process",
+			bpmn:     `<definitions></definitions>`,
+			expected: "exactly one process",
+		},
+		{
+			name:     "no start event",
+			bpmn:     `<definitions><process><task id="a"/></process></definitions>`,
+			expected: "no start event",
+		},
+		{
+			name: "gateway",
+			bpmn: `<definitions><process>
+				<startEvent id="s"/>
+				<exclusiveGateway id="g1"/>
+			</process></definitions>`,
+			expected: "unsupported BPMN element: exclusiveGateway (id: g1)",
+		},
+		{
+			name: "timer start event",
+			bpmn: `<definitions><process>
+				<startEvent id="s"><timerEventDefinition/></startEvent>
+			</process></definitions>`,
+			expected: "unsupported BPMN element: timerEventDefinition",
+		},
+		{
+			name: "branching",
+			bpmn: `<definitions><process>
+				<startEvent id="s"/>
+				<task id="a"/><task id="b"/>
+				<sequenceFlow id="f1" sourceRef="s" targetRef="a"/>
+				<sequenceFlow id="f2" sourceRef="s" targetRef="b"/>
+			</process></definitions>`,
+			expected: "more than one outgoing sequence flow",
+		},
+		{
+			name: "disconnected task",
+			bpmn: `<definitions><process>
+				<startEvent id="s"/>
+				<task id="a"/><task id="b"/>
+				<sequenceFlow id="f1" sourceRef="s" targetRef="a"/>
+			</process></definitions>`,
+			expected: "task b is not on the path",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := swf.NewWorkflowFromBPMN(test.bpmn)
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+
+			if !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Expected error containing %q, got %q", test.expected, err.Error())
+			}
+		})
+	}
+}
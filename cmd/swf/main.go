@@ -0,0 +1,425 @@
+// Command swf validates, visualizes and inspects workflows from the shell.
+//
+// Usage:
+//
+//	swf validate <definition>
+//	swf render [-format dot|mermaid|plantuml|svg|bpmn] [-state <state>] [-title <title>] <definition>
+//	swf init <definition> <state>
+//	swf inspect [-definition <definition>] [-repair] <state>
+//	swf apply -definition <definition> [-repair] <state> advance|complete|reject|goto <step>
+//
+// Definitions are JSON files (see swf.Definition), or BPMN 2.0 files with the
+// .bpmn or .xml extension. States are files with a serialized WorkflowState,
+// as returned by Workflow.ToString, validated against the definition. With
+// -repair, missing step details are filled in. Rendering to SVG requires
+// Graphviz (dot).
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dracory/swf"
+	"github.com/samber/lo"
+)
+
+const usage = `Usage:
+  swf validate <definition>
+  swf render [-format dot|mermaid|plantuml|svg|bpmn] [-state <state>] [-title <title>] <definition>
+  swf init <definition> <state>
+  swf inspect [-definition <definition>] [-repair] <state>
+  swf apply -definition <definition> [-repair] <state> advance|complete|reject|goto <step>
+`
+
+// errUsage is returned for invalid command lines
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command, returning the exit code
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "validate":
+		err = validateCommand(args[1:], stdout)
+	case "render":
+		err = renderCommand(args[1:], stdout)
+	case "init":
+		err = initCommand(args[1:], stdout)
+	case "inspect":
+		err = inspectCommand(args[1:], stdout)
+	case "apply":
+		err = applyCommand(args[1:], stdout)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		err = fmt.Errorf("%w: unknown command %s", errUsage, args[0])
+	}
+
+	if errors.Is(err, errUsage) {
+		fmt.Fprintf(stderr, "%v\n\n%s", err, usage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// validateCommand checks that a definition file is valid
+func validateCommand(args []string, stdout io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: validate expects a definition file", errUsage)
+	}
+
+	wf, err := loadDefinition(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "%s: valid, %d steps\n", args[0], len(wf.GetSteps()))
+	return nil
+}
+
+// renderCommand renders a workflow definition, optionally with a state
+func renderCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	format := flags.String("format", "dot", "output format: dot, mermaid, plantuml, svg or bpmn")
+	statePath := flags.String("state", "", "state file to render the status of the steps")
+	title := flags.String("title", "", "title template of the DOT graph, with the workflow metadata")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return fmt.Errorf("%w: render expects a definition file", errUsage)
+	}
+
+	wf, err := loadDefinition(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *statePath != "" {
+		if err := loadState(wf, *statePath, false, io.Discard); err != nil {
+			return err
+		}
+	}
+
+	var output string
+	switch *format {
+	case "dot":
+		output = wf.VisualizeWithOptions(swf.VisualizeOptions{Title: *title})
+	case "mermaid":
+		output = wf.VisualizeMermaid()
+	case "plantuml":
+		output = wf.VisualizePlantUML()
+	case "bpmn":
+		output, err = wf.ToBPMN()
+	case "svg":
+		output, err = renderSVG(wf.VisualizeWithOptions(swf.VisualizeOptions{Title: *title}))
+	default:
+		return fmt.Errorf("%w: unknown format %s", errUsage, *format)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, strings.TrimRight(output, "\n"))
+	return nil
+}
+
+// initCommand writes the initial state of a workflow to a state file
+func initCommand(args []string, stdout io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%w: init expects a definition and a state file", errUsage)
+	}
+
+	wf, err := loadDefinition(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := saveState(wf, args[1]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "%s: created at step %s\n", args[1], wf.GetState().CurrentStepName)
+	return nil
+}
+
+// inspectCommand pretty-prints a state, with the progress if the definition is given
+func inspectCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	definitionPath := flags.String("definition", "", "definition file, to show the progress")
+	repair := flags.Bool("repair", false, "repair the missing step details")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return fmt.Errorf("%w: inspect expects a state file", errUsage)
+	}
+
+	wf := swf.NewWorkflow()
+	if *definitionPath != "" {
+		var err error
+		if wf, err = loadDefinition(*definitionPath); err != nil {
+			return err
+		}
+	}
+
+	if err := loadState(wf, flags.Arg(0), *repair, stdout); err != nil {
+		return err
+	}
+
+	printState(stdout, wf)
+	return nil
+}
+
+// applyCommand applies a transition to a state file
+func applyCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("apply", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	definitionPath := flags.String("definition", "", "definition file")
+	repair := flags.Bool("repair", false, "repair the missing step details")
+
+	if err := flags.Parse(args); err != nil || *definitionPath == "" || flags.NArg() < 2 {
+		return fmt.Errorf("%w: apply expects a definition, a state file and a transition", errUsage)
+	}
+
+	statePath := flags.Arg(0)
+	transition := flags.Arg(1)
+
+	wf, err := loadDefinition(*definitionPath)
+	if err != nil {
+		return err
+	}
+
+	if err := loadState(wf, statePath, *repair, stdout); err != nil {
+		return err
+	}
+
+	switch transition {
+	case "advance":
+		err = wf.AdvanceCurrentStep()
+	case "complete":
+		err = wf.CompleteCurrentStep()
+	case "reject":
+		err = wf.RejectCurrentStep()
+	case "goto":
+		if flags.NArg() != 3 {
+			return fmt.Errorf("%w: goto expects a step name", errUsage)
+		}
+		err = wf.SetCurrentStep(flags.Arg(2))
+	default:
+		return fmt.Errorf("%w: unknown transition %s", errUsage, transition)
+	}
+
+	// A failed automated step is recorded in the state, which is saved
+	if err != nil && !errors.Is(err, swf.ErrStepFailed) {
+		return err
+	}
+
+	if err := saveState(wf, statePath); err != nil {
+		return err
+	}
+
+	printState(stdout, wf)
+	return err
+}
+
+// printState pretty-prints the state of a workflow
+func printState(out io.Writer, wf *swf.Workflow) {
+	state := wf.GetState()
+
+	if state.Version != "" {
+		fmt.Fprintf(out, "Version: %s\n", state.Version)
+	}
+
+	fmt.Fprintf(out, "Current step: %s\n", state.CurrentStepName)
+
+	if len(wf.GetSteps()) > 0 {
+		progress := wf.GetProgress()
+		fmt.Fprintf(out, "Progress: %d/%d steps completed (%.2f%%)\n", progress.Completed, progress.Total, progress.Percents)
+		if wf.IsFinished() {
+			fmt.Fprintln(out, "Finished: yes")
+		}
+	}
+
+	fmt.Fprintf(out, "History: %s\n", strings.Join(state.History, " -> "))
+
+	for _, key := range sortedKeys(state.Meta) {
+		fmt.Fprintf(out, "Meta %s: %v\n", key, state.Meta[key])
+	}
+
+	if len(state.Data) > 0 {
+		fmt.Fprintf(out, "Data: %s\n", state.Data)
+	}
+
+	fmt.Fprintln(out, "Steps:")
+	for _, name := range stepNames(wf) {
+		details := state.StepDetails[name]
+
+		status := "pending"
+		if wf.IsStepCurrent(name) {
+			status = "current"
+		} else if len(wf.GetSteps()) > 0 && wf.IsStepComplete(name) {
+			status = "completed"
+		} else if details != nil && details.Completed != "" {
+			status = "completed"
+		}
+
+		fmt.Fprintf(out, "  %s [%s]\n", name, status)
+		if details == nil {
+			fmt.Fprintln(out, "    (no details)")
+			continue
+		}
+
+		if details.Started != "" {
+			fmt.Fprintf(out, "    started:   %s\n", details.Started)
+		}
+		if details.Completed != "" {
+			fmt.Fprintf(out, "    completed: %s\n", details.Completed)
+		}
+		if duration, ok := details.Duration(); ok {
+			fmt.Fprintf(out, "    duration:  %s\n", duration)
+		}
+		if details.Error != "" {
+			fmt.Fprintf(out, "    failed:    %s (%d attempts)\n", details.Error, details.Attempts)
+		}
+		for _, key := range sortedKeys(details.Meta) {
+			fmt.Fprintf(out, "    meta %s: %v\n", key, details.Meta[key])
+			for _, change := range wf.GetStepMetaHistory(name, key) {
+				fmt.Fprintf(out, "      %s: %s\n", change.Time, change)
+			}
+		}
+	}
+}
+
+// stepNames returns the names of the steps, from the definition if available,
+// otherwise from the state
+func stepNames(wf *swf.Workflow) []string {
+	names := []string{}
+	for _, step := range wf.GetSteps() {
+		names = append(names, step.Name)
+	}
+
+	if len(names) > 0 {
+		return names
+	}
+
+	for _, name := range wf.GetState().History {
+		if !lo.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range sortedKeys(wf.GetState().StepDetails) {
+		if !lo.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// loadDefinition loads a workflow definition from a JSON or BPMN file
+func loadDefinition(path string) (*swf.Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".bpmn", ".xml":
+		return swf.NewWorkflowFromBPMN(string(data))
+	}
+
+	definition, err := swf.NewDefinitionFromString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid definition %s: %w", path, err)
+	}
+
+	return definition.NewWorkflow()
+}
+
+// loadState loads a serialized state file into the workflow,
+// optionally repairing it and reporting the repaired issues
+func loadState(wf *swf.Workflow, path string, repair bool, out io.Writer) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if !repair {
+		if err := wf.FromString(string(data)); err != nil {
+			return fmt.Errorf("invalid state %s: %w", path, err)
+		}
+		return nil
+	}
+
+	repaired, err := wf.FromStringRepair(string(data))
+	for _, issue := range repaired {
+		fmt.Fprintf(out, "Repaired: %s\n", issue.Message)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid state %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// saveState writes the serialized state of the workflow to a file
+func saveState(wf *swf.Workflow, path string) error {
+	state, err := wf.ToString()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(state+"\n"), 0o644)
+}
+
+// renderSVG renders a DOT graph to SVG with Graphviz
+func renderSVG(dot string) (string, error) {
+	if _, err := exec.LookPath("dot"); err != nil {
+		return "", fmt.Errorf("rendering SVG requires Graphviz (dot) in the PATH")
+	}
+
+	cmd := exec.Command("dot", "-Tsvg")
+	cmd.Stdin = strings.NewReader(dot)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("dot: %v: %s", err, stderr.String())
+	}
+
+	return stdout.String(), nil
+}
+
+// sortedKeys returns the keys of a map in ascending order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := lo.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDefinition = `{"Steps": [
+	{"Name": "review", "Title": "Review", "Responsible": "editor"},
+	{"Name": "approve", "Title": "Approve", "Responsible": "manager"},
+	{"Name": "publish", "Title": "Publish"}
+]}`
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func runCommand(args ...string) (int, string, string) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	code := run(args, stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunUsage(t *testing.T) {
+	if code, _, stderr := runCommand(); code != 2 || !strings.Contains(stderr, "Usage:") {
+		t.Errorf("Expected usage with exit code 2, got %d: %s", code, stderr)
+	}
+
+	if code, _, stderr := runCommand("unknown"); code != 2 || !strings.Contains(stderr, "unknown command unknown") {
+		t.Errorf("Expected unknown command with exit code 2, got %d: %s", code, stderr)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	definition := writeFile(t, "workflow.json", testDefinition)
+
+	code, stdout, _ := runCommand("validate", definition)
+	if code != 0 || !strings.Contains(stdout, "valid, 3 steps") {
+		t.Errorf("Expected valid definition, got %d: %s", code, stdout)
+	}
+
+	invalid := writeFile(t, "invalid.json", `{"Steps": [{"Name": "a"}, {"Name": "a"}]}`)
+
+	code, _, stderr := runCommand("validate", invalid)
+	if code != 1 || !strings.Contains(stderr, "step already exists: a") {
+		t.Errorf("Expected invalid definition, got %d: %s", code, stderr)
+	}
+
+	typo := writeFile(t, "typo.json", `{"Steps": [{"Name": "legal", "Responsable": "legal"}]}`)
+
+	code, _, stderr = runCommand("validate", typo)
+	if code != 1 || !strings.Contains(stderr, `unknown field "Responsable"`) {
+		t.Errorf("Expected unknown field error, got %d: %s", code, stderr)
+	}
+}
+
+func TestRender(t *testing.T) {
+	definition := writeFile(t, "workflow.json", testDefinition)
+
+	code, stdout, _ := runCommand("render", definition)
+	if code != 0 || !strings.Contains(stdout, "digraph") {
+		t.Errorf("Expected DOT output, got %d: %s", code, stdout)
+	}
+
+	code, stdout, _ = runCommand("render", "-format", "mermaid", definition)
+	if code != 0 || !strings.Contains(stdout, "review --> approve") {
+		t.Errorf("Expected Mermaid output, got %d: %s", code, stdout)
+	}
+
+	code, _, stderr := runCommand("render", "-format", "png", definition)
+	if code != 2 || !strings.Contains(stderr, "unknown format png") {
+		t.Errorf("Expected unknown format, got %d: %s", code, stderr)
+	}
+}
+
+func TestInitApplyInspect(t *testing.T) {
+	definition := writeFile(t, "workflow.json", testDefinition)
+	state := filepath.Join(t.TempDir(), "state.json")
+
+	if code, _, stderr := runCommand("init", definition, state); code != 0 {
+		t.Fatalf("Expected init to succeed, got %d: %s", code, stderr)
+	}
+
+	code, stdout, stderr := runCommand("apply", "-definition", definition, state, "advance")
+	if code != 0 {
+		t.Fatalf("Expected apply to succeed, got %d: %s", code, stderr)
+	}
+
+	if !strings.Contains(stdout, "Current step: approve") {
+		t.Errorf("Expected current step approve, got %s", stdout)
+	}
+
+	code, stdout, _ = runCommand("inspect", "-definition", definition, state)
+	if code != 0 {
+		t.Fatalf("Expected inspect to succeed, got %d", code)
+	}
+
+	expected := []string{
+		"Current step: approve",
+		"Progress: 1/3 steps completed",
+		"History: review -> approve",
+		"review [completed]",
+		"approve [current]",
+		"publish [pending]",
+	}
+
+	for _, fragment := range expected {
+		if !strings.Contains(stdout, fragment) {
+			t.Errorf("Expected output to contain %s, got %s", fragment, stdout)
+		}
+	}
+
+	code, stdout, _ = runCommand("apply", "-definition", definition, state, "reject")
+	if code != 0 || !strings.Contains(stdout, "Current step: review") {
+		t.Errorf("Expected reject back to review, got %d: %s", code, stdout)
+	}
+
+	code, _, stderr = runCommand("apply", "-definition", definition, state, "goto", "missing")
+	if code != 1 || stderr == "" {
+		t.Errorf("Expected goto to an unknown step to fail, got %d", code)
+	}
+
+	// Without a definition, the steps are taken from the state
+	code, stdout, _ = runCommand("inspect", state)
+	if code != 0 || !strings.Contains(stdout, "publish [pending]") || strings.Contains(stdout, "Progress:") {
+		t.Errorf("Expected inspect without definition, got %d: %s", code, stdout)
+	}
+}
+
+func TestInspectRepair(t *testing.T) {
+	definition := writeFile(t, "workflow.json", testDefinition)
+	state := writeFile(t, "state.json", `{"CurrentStepName":"review","History":["review"],"StepDetails":{"review":{}}}`)
+
+	code, _, stderr := runCommand("inspect", "-definition", definition, state)
+	if code != 1 || !strings.Contains(stderr, "missing details of step approve") {
+		t.Errorf("Expected invalid state, got %d: %s", code, stderr)
+	}
+
+	code, stdout, _ := runCommand("inspect", "-definition", definition, "-repair", state)
+	if code != 0 || !strings.Contains(stdout, "Repaired: missing details of step approve") {
+		t.Errorf("Expected repaired state, got %d: %s", code, stdout)
+	}
+}
@@ -0,0 +1,134 @@
+package swf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Definition is the serializable definition of a workflow, i.e. its steps,
+// e.g. to keep workflow definitions in files
+//
+// The JSON form is an object with the version and the list of steps,
+// using the Step field names:
+//
+//	{"Version": "2", "Steps": [{"Name": "review", "Title": "Review", "Responsible": "editor"}]}
+type Definition struct {
+	// Version identifies the definition, stored with each instance, see Migrator
+	Version string `json:",omitempty"`
+
+	Steps []*Step
+}
+
+// NewDefinitionFromString parses a JSON workflow definition.
+// Fields missing from a step take the defaults of NewStep,
+// unknown fields are rejected to catch typos.
+func NewDefinitionFromString(str string) (*Definition, error) {
+	raw := struct {
+		Version string
+		Steps   []json.RawMessage
+	}{}
+
+	if err := decodeStrict([]byte(str), &raw); err != nil {
+		return nil, err
+	}
+
+	definition := &Definition{
+		Version: raw.Version,
+		Steps:   make([]*Step, 0, len(raw.Steps)),
+	}
+	for index, data := range raw.Steps {
+		step := NewStep("")
+		if err := decodeStrict(data, step); err != nil {
+			return nil, fmt.Errorf("step %d: %w", index+1, err)
+		}
+		definition.Steps = append(definition.Steps, step)
+	}
+
+	return definition, nil
+}
+
+// decodeStrict decodes JSON, rejecting unknown fields
+func decodeStrict(data []byte, value any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(value)
+}
+
+// Validate checks that the definition can be used to create a workflow
+//
+// Business logic:
+// 1. Check there is at least one step
+// 2. Check each step has a name, unique within the workflow
+// 3. Check step weights are not negative
+// 4. Check step guards are valid templates
+func (d *Definition) Validate() error {
+	if len(d.Steps) == 0 {
+		return fmt.Errorf("definition has no steps")
+	}
+
+	names := map[string]bool{}
+	for i, step := range d.Steps {
+		if step == nil {
+			return fmt.Errorf("step %d is empty", i+1)
+		}
+
+		if step.Name == "" {
+			return fmt.Errorf("step %d has no name", i+1)
+		}
+
+		if names[step.Name] {
+			return fmt.Errorf("step already exists: %s", step.Name)
+		}
+		names[step.Name] = true
+
+		if step.Weight < 0 {
+			return fmt.Errorf("step weight must not be negative: %s", step.Name)
+		}
+
+		if _, err := parseGuard(step); err != nil {
+			return fmt.Errorf("step guard is invalid: %s: %w", step.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// NewWorkflow creates a new workflow with the steps of the definition
+func (d *Definition) NewWorkflow() (*Workflow, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	workflow := NewWorkflow()
+	workflow.SetVersion(d.Version)
+
+	for _, step := range d.Steps {
+		copied := *step
+		if err := workflow.AddStep(&copied); err != nil {
+			return nil, err
+		}
+	}
+
+	return workflow, nil
+}
+
+// ToString serializes the definition to JSON
+func (d *Definition) ToString() (string, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetDefinition returns the definition of the workflow, i.e. its version and steps
+func (w *Workflow) GetDefinition() *Definition {
+	steps := make([]*Step, 0, len(w.steps))
+	for _, step := range w.steps {
+		copied := *step
+		steps = append(steps, &copied)
+	}
+
+	return &Definition{Version: w.version, Steps: steps}
+}
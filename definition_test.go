@@ -0,0 +1,118 @@
+package swf_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestNewDefinitionFromString(t *testing.T) {
+	definition, err := swf.NewDefinitionFromString(`{"Steps": [
+		{"Name": "review", "Title": "Review", "Responsible": "editor"},
+		{"Name": "publish", "Weight": 2}
+	]}`)
+	if err != nil {
+		t.Fatalf("NewDefinitionFromString failed: %v", err)
+	}
+
+	if len(definition.Steps) != 2 {
+		t.Fatalf("Expected 2 steps, got %d", len(definition.Steps))
+	}
+
+	if definition.Steps[0].Responsible != "editor" {
+		t.Errorf("Expected responsible 'editor', got %s", definition.Steps[0].Responsible)
+	}
+
+	// Missing fields take the defaults of NewStep
+	if definition.Steps[1].Responsible != "Admin" {
+		t.Errorf("Expected default responsible 'Admin', got %s", definition.Steps[1].Responsible)
+	}
+
+	if definition.Steps[1].Weight != 2 {
+		t.Errorf("Expected weight 2, got %v", definition.Steps[1].Weight)
+	}
+
+	if _, err := swf.NewDefinitionFromString(`{`); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+
+	// Unknown fields are typos
+	_, err = swf.NewDefinitionFromString(`{"Steps": [{"Name": "review"}, {"Name": "legal", "Responsable": "legal"}]}`)
+	if err == nil || !strings.Contains(err.Error(), "step 2") || !strings.Contains(err.Error(), "Responsable") {
+		t.Errorf("Expected error for the unknown field of step 2, got %v", err)
+	}
+
+	if _, err := swf.NewDefinitionFromString(`{"Stpes": []}`); err == nil {
+		t.Error("Expected error for an unknown top-level field")
+	}
+}
+
+func TestDefinitionValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected string
+	}{
+		{name: "no steps", json: `{"Steps": []}`, expected: "no steps"},
+		{name: "no name", json: `{"Steps": [{"Title": "A"}]}`, expected: "step 1 has no name"},
+		{name: "duplicate", json: `{"Steps": [{"Name": "a"}, {"Name": "a"}]}`, expected: "step already exists: a"},
+		{name: "negative weight", json: `{"Steps": [{"Name": "a", "Weight": -1}]}`, expected: "must not be negative"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			definition, err := swf.NewDefinitionFromString(test.json)
+			if err != nil {
+				t.Fatalf("NewDefinitionFromString failed: %v", err)
+			}
+
+			err = definition.Validate()
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Expected error containing %q, got %v", test.expected, err)
+			}
+
+			if _, err := definition.NewWorkflow(); err == nil {
+				t.Error("Expected NewWorkflow to fail")
+			}
+		})
+	}
+}
+
+func TestDefinitionRoundTrip(t *testing.T) {
+	wf := swf.NewWorkflow()
+	step1 := swf.NewStep("step1")
+	step1.Title = "First"
+	wf.AddStep(step1)
+	wf.AddStep(swf.NewStep("step2"))
+
+	str, err := wf.GetDefinition().ToString()
+	if err != nil {
+		t.Fatalf("ToString failed: %v", err)
+	}
+
+	definition, err := swf.NewDefinitionFromString(str)
+	if err != nil {
+		t.Fatalf("NewDefinitionFromString failed: %v", err)
+	}
+
+	created, err := definition.NewWorkflow()
+	if err != nil {
+		t.Fatalf("NewWorkflow failed: %v", err)
+	}
+
+	steps := created.GetSteps()
+	if len(steps) != 2 || steps[0].Title != "First" || steps[1].Name != "step2" {
+		t.Errorf("Expected steps step1, step2, got %v", steps)
+	}
+
+	if created.GetState().CurrentStepName != "step1" {
+		t.Errorf("Expected current step 'step1', got %s", created.GetState().CurrentStepName)
+	}
+
+	// The workflow does not share steps with the definition
+	definition.Steps[0].Title = "Changed"
+	if created.GetStep("step1").Title != "First" {
+		t.Error("Expected workflow steps to be copies of the definition steps")
+	}
+}
@@ -0,0 +1,67 @@
+package swf
+
+import "errors"
+
+// ErrInstanceNotFound is returned by a Store when an instance does not exist
+var ErrInstanceNotFound = errors.New("instance not found")
+
+// ErrInstanceExists is returned by a Store when creating an instance with an existing ID
+var ErrInstanceExists = errors.New("instance already exists")
+
+// ErrNoCurrentStep is returned by transitions when the workflow has no current step
+var ErrNoCurrentStep = errors.New("workflow has no current step")
+
+// ErrWorkflowFinished is returned when advancing a workflow with all steps completed
+var ErrWorkflowFinished = errors.New("workflow is already finished")
+
+// ErrNoPreviousStep is returned when rejecting the first step of a workflow
+var ErrNoPreviousStep = errors.New("no previous step to return to")
+
+// ErrLinkInvalid is returned when an approval link is malformed or its signature is invalid
+var ErrLinkInvalid = errors.New("invalid approval link")
+
+// ErrLinkExpired is returned when an approval link has expired
+var ErrLinkExpired = errors.New("approval link has expired")
+
+// ErrStepNotCurrent is returned when acting on a step which is no longer the current step
+var ErrStepNotCurrent = errors.New("step is not current")
+
+// ErrMetaNotFound is returned when reading a step metadata key which is not set
+var ErrMetaNotFound = errors.New("step meta not found")
+
+// ErrStepNotFound is returned when referencing a step which is not in the workflow
+var ErrStepNotFound = errors.New("step not found")
+
+// ErrInvalidStepRef is returned when a step is referenced by something else
+// than a step name or a step pointer
+var ErrInvalidStepRef = errors.New("invalid step reference")
+
+// ErrInvalidState is returned when loading a state which does not match the steps of the workflow
+var ErrInvalidState = errors.New("invalid workflow state")
+
+// ErrMigrationNotFound is returned when no registered migrations lead from the state version to the workflow version
+var ErrMigrationNotFound = errors.New("no migration found")
+
+// ErrMissingParameters is returned when instantiating a template without all its parameters
+var ErrMissingParameters = errors.New("missing template parameters")
+
+// ErrFormInvalid is returned when submitted form data does not match the form schema of a step
+var ErrFormInvalid = errors.New("invalid form data")
+
+// ErrStepIncomplete is returned when completing a step whose type does not allow it yet,
+// e.g. an approval step which has not been approved
+var ErrStepIncomplete = errors.New("step cannot be completed yet")
+
+// ErrNotApprovalStep is returned when voting on a step which is not an approval step
+var ErrNotApprovalStep = errors.New("not an approval step")
+
+// ErrNotApprover is returned when an actor who is not an approver of the step votes on it
+var ErrNotApprover = errors.New("not an approver")
+
+// ErrNoHandler is returned when an automated step has no registered handler
+var ErrNoHandler = errors.New("no handler registered")
+
+// ErrStepFailed is returned when the handler of an automated step failed.
+// The transition into the step did happen and the failure is recorded in the
+// step details, so the state must still be saved.
+var ErrStepFailed = errors.New("automated step failed")
@@ -0,0 +1,155 @@
+package swf
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// StepDurationStats contains duration statistics of a step,
+// collected from completed workflow instances
+type StepDurationStats struct {
+	Count  int
+	Median time.Duration
+	P90    time.Duration
+}
+
+// Estimate represents the workflow progress with an estimation
+// of the remaining time, based on historical step durations
+type Estimate struct {
+	*Progress
+
+	// Remaining is the estimated remaining time, based on median durations
+	Remaining time.Duration
+
+	// RemainingP90 is the pessimistic remaining time, based on p90 durations
+	RemainingP90 time.Duration
+
+	// ETA is the estimated completion time, based on median durations
+	ETA time.Time
+
+	// ETAP90 is the pessimistic completion time, based on p90 durations
+	ETAP90 time.Time
+
+	// UnknownSteps lists the remaining steps without historical durations,
+	// which are not included in the estimation
+	UnknownSteps []string
+}
+
+// Estimator estimates the remaining time of workflow instances
+// from the step durations of completed instances
+type Estimator struct {
+	stats map[string]*StepDurationStats
+}
+
+// NewEstimator creates a new Estimator from the states of completed workflows
+//
+// Business logic:
+// 1. Collect the duration of each step that was both started and completed
+// 2. Calculate the median and p90 duration per step
+func NewEstimator(states []*WorkflowState) *Estimator {
+	durations := map[string][]time.Duration{}
+
+	for _, state := range states {
+		if state == nil {
+			continue
+		}
+
+		for name, details := range state.StepDetails {
+			if details == nil {
+				continue
+			}
+
+			duration, ok := details.Duration()
+			if !ok {
+				continue
+			}
+
+			durations[name] = append(durations[name], duration)
+		}
+	}
+
+	stats := make(map[string]*StepDurationStats, len(durations))
+	for name, values := range durations {
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		stats[name] = &StepDurationStats{
+			Count:  len(values),
+			Median: DurationPercentile(values, 50),
+			P90:    DurationPercentile(values, 90),
+		}
+	}
+
+	return &Estimator{stats: stats}
+}
+
+// GetStepStats returns the duration statistics of a step,
+// or nil if there is no historical data for the step
+func (e *Estimator) GetStepStats(stepName string) *StepDurationStats {
+	return e.stats[stepName]
+}
+
+// Estimate estimates the remaining time of a workflow instance
+func (e *Estimator) Estimate(w *Workflow) *Estimate {
+	return e.EstimateAt(w, time.Now())
+}
+
+// EstimateAt estimates the remaining time of a workflow instance at the given time
+//
+// Business logic:
+// 1. Completed steps take no more time
+// 2. The current step takes its historical duration, minus the time already spent on it
+// 3. Pending steps take their historical duration
+// 4. Steps without historical data are reported as unknown
+func (e *Estimator) EstimateAt(w *Workflow, now time.Time) *Estimate {
+	estimate := &Estimate{
+		Progress:     w.GetProgress(),
+		UnknownSteps: []string{},
+	}
+
+	for _, step := range w.steps {
+		if w.IsStepComplete(step) {
+			continue
+		}
+
+		stats := e.stats[step.Name]
+		if stats == nil {
+			estimate.UnknownSteps = append(estimate.UnknownSteps, step.Name)
+			continue
+		}
+
+		median := stats.Median
+		p90 := stats.P90
+
+		if w.IsStepCurrent(step) {
+			elapsed := time.Duration(0)
+			if details := w.state.StepDetails[step.Name]; details != nil {
+				if started, ok := details.StartedAt(); ok && now.After(started) {
+					elapsed = now.Sub(started)
+				}
+			}
+			median = max(median-elapsed, 0)
+			p90 = max(p90-elapsed, 0)
+		}
+
+		estimate.Remaining += median
+		estimate.RemainingP90 += p90
+	}
+
+	estimate.ETA = now.Add(estimate.Remaining)
+	estimate.ETAP90 = now.Add(estimate.RemainingP90)
+
+	return estimate
+}
+
+// DurationPercentile returns the p-th percentile (nearest rank)
+// of durations sorted in ascending order
+func DurationPercentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(float64(len(sorted))*p/100)) - 1
+	rank = min(max(rank, 0), len(sorted)-1)
+
+	return sorted[rank]
+}
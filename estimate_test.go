@@ -0,0 +1,118 @@
+package swf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dracory/swf"
+)
+
+// completedState returns the state of a completed instance with the given step durations
+func completedState(start time.Time, durations map[string]time.Duration, order []string) *swf.WorkflowState {
+	state := &swf.WorkflowState{
+		CurrentStepName: order[len(order)-1],
+		History:         order,
+		StepDetails:     map[string]*swf.StepDetails{},
+	}
+
+	for _, name := range order {
+		end := start.Add(durations[name])
+		state.StepDetails[name] = &swf.StepDetails{
+			Started:   start.Format(time.RFC3339),
+			Completed: end.Format(time.RFC3339),
+			Meta:      map[string]any{},
+		}
+		start = end
+	}
+
+	return state
+}
+
+func TestEstimator(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	order := []string{"review", "approve", "publish"}
+
+	states := []*swf.WorkflowState{}
+	for i := 1; i <= 10; i++ {
+		states = append(states, completedState(start, map[string]time.Duration{
+			"review":  time.Duration(i) * time.Hour,
+			"approve": 2 * time.Hour,
+		}, order[:2]))
+	}
+
+	estimator := swf.NewEstimator(states)
+
+	stats := estimator.GetStepStats("review")
+	if stats == nil {
+		t.Fatal("Expected stats for review")
+	}
+
+	if stats.Count != 10 {
+		t.Errorf("Expected 10 durations, got %d", stats.Count)
+	}
+
+	if stats.Median != 5*time.Hour {
+		t.Errorf("Expected median 5h, got %s", stats.Median)
+	}
+
+	if stats.P90 != 9*time.Hour {
+		t.Errorf("Expected p90 9h, got %s", stats.P90)
+	}
+
+	if estimator.GetStepStats("publish") != nil {
+		t.Error("Expected no stats for publish")
+	}
+
+	// In-flight instance, 1 hour into the review step
+	wf := swf.NewWorkflow()
+	for _, name := range order {
+		wf.AddStep(swf.NewStep(name))
+	}
+	wf.GetState().StepDetails["review"].Started = start.Format(time.RFC3339)
+
+	now := start.Add(time.Hour)
+	estimate := estimator.EstimateAt(wf, now)
+
+	if estimate.Progress == nil || estimate.Total != 3 {
+		t.Fatal("Expected estimate to include progress")
+	}
+
+	// 4h left of review + 2h approve
+	if estimate.Remaining != 6*time.Hour {
+		t.Errorf("Expected 6h remaining, got %s", estimate.Remaining)
+	}
+
+	// 8h left of review + 2h approve
+	if estimate.RemainingP90 != 10*time.Hour {
+		t.Errorf("Expected 10h remaining at p90, got %s", estimate.RemainingP90)
+	}
+
+	if !estimate.ETA.Equal(now.Add(6 * time.Hour)) {
+		t.Errorf("Expected ETA %s, got %s", now.Add(6*time.Hour), estimate.ETA)
+	}
+
+	if len(estimate.UnknownSteps) != 1 || estimate.UnknownSteps[0] != "publish" {
+		t.Errorf("Expected publish to be unknown, got %v", estimate.UnknownSteps)
+	}
+
+	// Overdue current step has nothing left
+	estimate = estimator.EstimateAt(wf, start.Add(12*time.Hour))
+	if estimate.Remaining != 2*time.Hour {
+		t.Errorf("Expected 2h remaining for overdue step, got %s", estimate.Remaining)
+	}
+}
+
+func TestDurationPercentile(t *testing.T) {
+	if swf.DurationPercentile(nil, 50) != 0 {
+		t.Error("Expected 0 for no durations")
+	}
+
+	durations := []time.Duration{1, 2, 3, 4}
+	if swf.DurationPercentile(durations, 50) != 2 {
+		t.Errorf("Expected median 2, got %d", swf.DurationPercentile(durations, 50))
+	}
+
+	if swf.DurationPercentile(durations, 90) != 4 {
+		t.Errorf("Expected p90 4, got %d", swf.DurationPercentile(durations, 90))
+	}
+}
@@ -0,0 +1,415 @@
+package swf
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Form field types
+const (
+	FormFieldTypeString  = "string"
+	FormFieldTypeNumber  = "number"
+	FormFieldTypeInteger = "integer"
+	FormFieldTypeBoolean = "boolean"
+)
+
+// FormSchema describes the data collected by a step, e.g. "enter invoice amount"
+type FormSchema struct {
+	// Title is the human-readable title of the form
+	Title string `json:",omitempty"`
+
+	// Fields are the fields of the form, in display order
+	Fields []*FormField
+}
+
+// FormField describes a field of a step form
+type FormField struct {
+	// Name is the key of the value in the step metadata
+	Name string
+
+	// Type is one of the FormFieldType constants, defaults to string
+	Type string `json:",omitempty"`
+
+	// Title is the human-readable label of the field
+	Title string `json:",omitempty"`
+
+	// Description is a help text for the field
+	Description string `json:",omitempty"`
+
+	// Required fields must be present and not empty
+	Required bool `json:",omitempty"`
+
+	// Min and Max are the bounds of numbers, or of the length of strings
+	Min *float64 `json:",omitempty"`
+	Max *float64 `json:",omitempty"`
+
+	// Pattern is a regular expression strings must match
+	Pattern string `json:",omitempty"`
+
+	// Enum lists the allowed values
+	Enum []any `json:",omitempty"`
+}
+
+// FormError is a validation error of a form field
+type FormError struct {
+	Field   string
+	Message string
+}
+
+// FormValidationError lists the validation errors of submitted form data
+type FormValidationError struct {
+	Errors []FormError
+}
+
+// Error returns the validation errors as a single message
+func (e *FormValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldError := range e.Errors {
+		messages = append(messages, fieldError.Field+": "+fieldError.Message)
+	}
+	return ErrFormInvalid.Error() + ": " + strings.Join(messages, "; ")
+}
+
+// Unwrap allows matching the error with errors.Is(err, ErrFormInvalid)
+func (e *FormValidationError) Unwrap() error {
+	return ErrFormInvalid
+}
+
+// Validate validates submitted form data against the schema
+//
+// Business logic:
+// 1. Check the schema itself is valid, e.g. its patterns compile
+// 2. Check required fields are present and not empty
+// 3. Convert values to the field types, accepting strings as submitted by HTML forms
+// 4. Check min/max, pattern and enum constraints
+// 5. Return the converted values of the fields, ignoring unknown keys
+func (s *FormSchema) Validate(data map[string]any) (map[string]any, error) {
+	if err := s.check(); err != nil {
+		return nil, fmt.Errorf("invalid form schema: %w", err)
+	}
+
+	values := map[string]any{}
+	validationError := &FormValidationError{}
+
+	for _, field := range s.Fields {
+		raw, exists := data[field.Name]
+		if !exists || raw == nil || raw == "" {
+			if field.Required {
+				validationError.Errors = append(validationError.Errors, FormError{Field: field.Name, Message: "is required"})
+			}
+			continue
+		}
+
+		value, err := field.validate(raw)
+		if err != nil {
+			validationError.Errors = append(validationError.Errors, FormError{Field: field.Name, Message: err.Error()})
+			continue
+		}
+
+		values[field.Name] = value
+	}
+
+	if len(validationError.Errors) > 0 {
+		return nil, validationError
+	}
+
+	return values, nil
+}
+
+// ToJSONSchema exports the form schema as a JSON Schema (draft 2020-12) document
+func (s *FormSchema) ToJSONSchema() (string, error) {
+	properties := map[string]any{}
+	required := []string{}
+
+	for _, field := range s.Fields {
+		property := map[string]any{"type": field.getType()}
+
+		if field.Title != "" {
+			property["title"] = field.Title
+		}
+		if field.Description != "" {
+			property["description"] = field.Description
+		}
+		if field.Pattern != "" {
+			property["pattern"] = field.Pattern
+		}
+		if len(field.Enum) > 0 {
+			property["enum"] = field.Enum
+		}
+
+		minKey, maxKey := "minimum", "maximum"
+		if field.getType() == FormFieldTypeString {
+			minKey, maxKey = "minLength", "maxLength"
+		}
+		if field.Min != nil {
+			property[minKey] = *field.Min
+		}
+		if field.Max != nil {
+			property[maxKey] = *field.Max
+		}
+
+		properties[field.Name] = property
+
+		if field.Required {
+			required = append(required, field.Name)
+		}
+	}
+
+	schema := map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+
+	if s.Title != "" {
+		schema["title"] = s.Title
+	}
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// check checks that the schema itself is valid, when adding the step
+func (s *FormSchema) check() error {
+	names := map[string]bool{}
+
+	for _, field := range s.Fields {
+		if field == nil || field.Name == "" {
+			return fmt.Errorf("form field has no name")
+		}
+
+		if names[field.Name] {
+			return fmt.Errorf("form field already exists: %s", field.Name)
+		}
+		names[field.Name] = true
+
+		switch field.getType() {
+		case FormFieldTypeString, FormFieldTypeNumber, FormFieldTypeInteger, FormFieldTypeBoolean:
+		default:
+			return fmt.Errorf("form field %s has unknown type: %s", field.Name, field.Type)
+		}
+
+		if field.Pattern != "" {
+			if _, err := compilePattern(field.Pattern); err != nil {
+				return fmt.Errorf("form field %s has invalid pattern: %w", field.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// patterns caches the compiled form field patterns
+var patterns sync.Map
+
+// compilePattern compiles a form field pattern, once
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if compiled, exists := patterns.Load(pattern); exists {
+		return compiled.(*regexp.Regexp), nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns.Store(pattern, compiled)
+	return compiled, nil
+}
+
+// getType returns the type of the field, defaulting to string
+func (f *FormField) getType() string {
+	if f.Type == "" {
+		return FormFieldTypeString
+	}
+	return f.Type
+}
+
+// validate converts a value to the field type and checks the constraints
+func (f *FormField) validate(raw any) (any, error) {
+	var value any
+
+	switch f.getType() {
+	case FormFieldTypeString:
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a string")
+		}
+
+		length := float64(utf8.RuneCountInString(str))
+		if f.Min != nil && length < *f.Min {
+			return nil, fmt.Errorf("must be at least %v characters", *f.Min)
+		}
+		if f.Max != nil && length > *f.Max {
+			return nil, fmt.Errorf("must be at most %v characters", *f.Max)
+		}
+
+		if f.Pattern != "" {
+			pattern, err := compilePattern(f.Pattern)
+			if err != nil {
+				return nil, err
+			}
+			if !pattern.MatchString(str) {
+				return nil, fmt.Errorf("must match %s", f.Pattern)
+			}
+		}
+
+		value = str
+	case FormFieldTypeNumber, FormFieldTypeInteger:
+		number, ok := toFloat(raw)
+		if !ok {
+			return nil, fmt.Errorf("must be a number")
+		}
+
+		if f.Min != nil && number < *f.Min {
+			return nil, fmt.Errorf("must be at least %v", *f.Min)
+		}
+		if f.Max != nil && number > *f.Max {
+			return nil, fmt.Errorf("must be at most %v", *f.Max)
+		}
+
+		value = number
+		if f.getType() == FormFieldTypeInteger {
+			if number != math.Trunc(number) {
+				return nil, fmt.Errorf("must be an integer")
+			}
+			value = int(number)
+		}
+	case FormFieldTypeBoolean:
+		switch typed := raw.(type) {
+		case bool:
+			value = typed
+		case string:
+			parsed, err := strconv.ParseBool(typed)
+			if err != nil {
+				return nil, fmt.Errorf("must be a boolean")
+			}
+			value = parsed
+		default:
+			return nil, fmt.Errorf("must be a boolean")
+		}
+	}
+
+	if len(f.Enum) > 0 && !f.allows(value) {
+		return nil, fmt.Errorf("must be one of %v", f.Enum)
+	}
+
+	return value, nil
+}
+
+// allows checks if a value is one of the enum values,
+// comparing their string representation, e.g. 1 matches 1.0
+func (f *FormField) allows(value any) bool {
+	for _, allowed := range f.Enum {
+		if number, ok := toFloat(allowed); ok {
+			if valueNumber, ok := toFloat(value); ok && number == valueNumber {
+				return true
+			}
+			continue
+		}
+
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// toFloat converts a finite number, or a string containing one, to float64.
+// NaN and infinities are rejected: they pass the min and max checks, and
+// cannot be serialized to JSON.
+func toFloat(value any) (float64, bool) {
+	number, ok := 0.0, false
+
+	switch typed := value.(type) {
+	case float64:
+		number, ok = typed, true
+	case float32:
+		number, ok = float64(typed), true
+	case int:
+		number, ok = float64(typed), true
+	case int32:
+		number, ok = float64(typed), true
+	case int64:
+		number, ok = float64(typed), true
+	case json.Number:
+		parsed, err := typed.Float64()
+		number, ok = parsed, err == nil
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		number, ok = parsed, err == nil
+	}
+
+	if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+	return number, true
+}
+
+// SubmitStepForm validates the data submitted for a step against its form,
+// and stores the validated values into the step metadata
+//
+// Business logic:
+// 1. Check the step exists and has a form
+// 2. Validate the data, returning a FormValidationError if invalid
+// 3. Store the converted values into the step metadata, in field order
+func (w *Workflow) SubmitStepForm(step any, data map[string]any) error {
+	stepName, err := stepName(step)
+	if err != nil {
+		return err
+	}
+
+	definition := w.GetStep(stepName)
+	if definition == nil || w.state.StepDetails[stepName] == nil {
+		return fmt.Errorf("%w: %s", ErrStepNotFound, stepName)
+	}
+
+	if definition.Form == nil {
+		return fmt.Errorf("step has no form: %s", stepName)
+	}
+
+	values, err := definition.Form.Validate(data)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range definition.Form.Fields {
+		if value, exists := values[field.Name]; exists {
+			w.setStepMeta(stepName, field.Name, value, "")
+		}
+	}
+
+	return nil
+}
+
+// ValidateStepForm checks that the metadata of a step satisfies its form,
+// steps without a form are always valid
+func (w *Workflow) ValidateStepForm(step any) error {
+	stepName, err := stepName(step)
+	if err != nil {
+		return err
+	}
+
+	definition := w.GetStep(stepName)
+	if definition == nil || definition.Form == nil {
+		return nil
+	}
+
+	var meta map[string]any
+	if details := w.state.StepDetails[stepName]; details != nil {
+		meta = details.Meta
+	}
+
+	_, err = definition.Form.Validate(meta)
+	return err
+}
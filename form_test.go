@@ -0,0 +1,188 @@
+package swf_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func ptr(value float64) *float64 {
+	return &value
+}
+
+func invoiceForm() *swf.FormSchema {
+	return &swf.FormSchema{
+		Title: "Invoice",
+		Fields: []*swf.FormField{
+			{Name: "amount", Type: swf.FormFieldTypeNumber, Required: true, Min: ptr(0), Max: ptr(10000)},
+			{Name: "items", Type: swf.FormFieldTypeInteger},
+			{Name: "reference", Pattern: `^INV-[0-9]+$`, Required: true},
+			{Name: "currency", Enum: []any{"EUR", "USD"}},
+			{Name: "paid", Type: swf.FormFieldTypeBoolean},
+		},
+	}
+}
+
+func TestFormSchemaValidate(t *testing.T) {
+	form := invoiceForm()
+
+	// Strings submitted by HTML forms are converted to the field types
+	values, err := form.Validate(map[string]any{
+		"amount":    "120.50",
+		"items":     float64(3),
+		"reference": "INV-42",
+		"currency":  "EUR",
+		"paid":      "true",
+		"unknown":   "ignored",
+	})
+	if err != nil {
+		t.Fatalf("Expected valid data, got %v", err)
+	}
+
+	if values["amount"] != 120.5 || values["items"] != 3 || values["paid"] != true {
+		t.Errorf("Expected converted values, got %v", values)
+	}
+
+	if _, exists := values["unknown"]; exists {
+		t.Error("Expected unknown keys to be ignored")
+	}
+
+	_, err = form.Validate(map[string]any{
+		"amount":    float64(-1),
+		"items":     1.5,
+		"reference": "42",
+		"currency":  "GBP",
+		"paid":      "maybe",
+	})
+
+	validationError := &swf.FormValidationError{}
+	if !errors.As(err, &validationError) || !errors.Is(err, swf.ErrFormInvalid) {
+		t.Fatalf("Expected FormValidationError, got %v", err)
+	}
+
+	if len(validationError.Errors) != 5 {
+		t.Errorf("Expected 5 field errors, got %v", validationError.Errors)
+	}
+
+	_, err = form.Validate(map[string]any{"amount": 1})
+	if err == nil || !strings.Contains(err.Error(), "reference: is required") {
+		t.Errorf("Expected required error, got %v", err)
+	}
+}
+
+func TestSubmitStepForm(t *testing.T) {
+	wf := swf.NewWorkflow()
+
+	invoice := swf.NewStep("invoice")
+	invoice.Form = invoiceForm()
+	wf.AddStep(invoice)
+	wf.AddStep(swf.NewStep("payment"))
+
+	// The step cannot be completed before the form is valid
+	if wf.MarkStepAsCompleted("invoice") {
+		t.Error("Expected MarkStepAsCompleted to fail without form data")
+	}
+
+	if err := wf.AdvanceCurrentStep(); !errors.Is(err, swf.ErrFormInvalid) {
+		t.Errorf("Expected ErrFormInvalid, got %v", err)
+	}
+
+	if err := wf.SubmitStepForm("invoice", map[string]any{"amount": "abc", "reference": "INV-1"}); err == nil {
+		t.Error("Expected error for invalid data")
+	}
+
+	if wf.GetStepMeta("invoice", "reference") != nil {
+		t.Error("Expected invalid data not to be stored")
+	}
+
+	for _, amount := range []any{"NaN", "+Inf", "-inf", math.Inf(1), math.NaN()} {
+		err := wf.SubmitStepForm("invoice", map[string]any{"amount": amount, "reference": "INV-1"})
+		if !errors.Is(err, swf.ErrFormInvalid) || !strings.Contains(err.Error(), "must be a number") {
+			t.Errorf("Expected %v to be rejected as not a number, got %v", amount, err)
+		}
+	}
+
+	if _, err := wf.ToString(); err != nil {
+		t.Errorf("Expected the state to stay serializable, got %v", err)
+	}
+
+	if err := wf.SubmitStepForm("invoice", map[string]any{"amount": 99, "reference": "INV-1"}); err != nil {
+		t.Fatalf("SubmitStepForm failed: %v", err)
+	}
+
+	if wf.GetStepMeta("invoice", "amount") != float64(99) {
+		t.Errorf("Expected amount 99, got %v", wf.GetStepMeta("invoice", "amount"))
+	}
+
+	if err := wf.AdvanceCurrentStep(); err != nil {
+		t.Errorf("Expected AdvanceCurrentStep to succeed, got %v", err)
+	}
+
+	if err := wf.SubmitStepForm("payment", map[string]any{}); err == nil {
+		t.Error("Expected error for step without form")
+	}
+}
+
+func TestFormSchemaCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		form *swf.FormSchema
+	}{
+		{name: "no name", form: &swf.FormSchema{Fields: []*swf.FormField{{Type: "string"}}}},
+		{name: "duplicate", form: &swf.FormSchema{Fields: []*swf.FormField{{Name: "a"}, {Name: "a"}}}},
+		{name: "unknown type", form: &swf.FormSchema{Fields: []*swf.FormField{{Name: "a", Type: "date"}}}},
+		{name: "invalid pattern", form: &swf.FormSchema{Fields: []*swf.FormField{{Name: "a", Pattern: "("}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			step := swf.NewStep("step")
+			step.Form = test.form
+
+			if err := swf.NewWorkflow().AddStep(step); err == nil {
+				t.Error("Expected AddStep to reject the form")
+			}
+
+			// Validating with an invalid schema returns an error, without panicking
+			if _, err := test.form.Validate(map[string]any{"a": "value"}); err == nil {
+				t.Error("Expected Validate to reject the form")
+			}
+		})
+	}
+}
+
+func TestFormSchemaToJSONSchema(t *testing.T) {
+	str, err := invoiceForm().ToJSONSchema()
+	if err != nil {
+		t.Fatalf("ToJSONSchema failed: %v", err)
+	}
+
+	schema := map[string]any{}
+	if err := json.Unmarshal([]byte(str), &schema); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	if schema["type"] != "object" || schema["title"] != "Invoice" {
+		t.Errorf("Expected object schema titled Invoice, got %v", schema)
+	}
+
+	properties := schema["properties"].(map[string]any)
+	amount := properties["amount"].(map[string]any)
+	if amount["type"] != "number" || amount["minimum"] != float64(0) || amount["maximum"] != float64(10000) {
+		t.Errorf("Expected number with bounds, got %v", amount)
+	}
+
+	reference := properties["reference"].(map[string]any)
+	if reference["type"] != "string" || reference["pattern"] != `^INV-[0-9]+$` {
+		t.Errorf("Expected string with pattern, got %v", reference)
+	}
+
+	required := schema["required"].([]any)
+	if len(required) != 2 || required[0] != "amount" || required[1] != "reference" {
+		t.Errorf("Expected required amount and reference, got %v", required)
+	}
+}
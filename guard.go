@@ -0,0 +1,49 @@
+package swf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// parseGuard parses the guard of a step, as a text/template
+// failing on missing metadata keys
+func parseGuard(step *Step) (*template.Template, error) {
+	return template.New(step.Name).Option("missingkey=error").Parse(step.Guard)
+}
+
+// checkGuard checks the guard of a step is satisfied by the workflow metadata
+//
+// Business logic:
+// 1. Steps without a guard are always satisfied
+// 2. Convert the metadata as serialized, numbers being float64 whether restored or not
+// 3. Execute the guard, failing on a missing metadata key
+// 4. The guard is satisfied when it renders "true", returning ErrStepIncomplete if not
+func (w *Workflow) checkGuard(step *Step) error {
+	if step.Guard == "" {
+		return nil
+	}
+
+	tmpl, err := parseGuard(step)
+	if err != nil {
+		return fmt.Errorf("%w: %s guard is invalid: %v", ErrStepIncomplete, step.Name, err)
+	}
+
+	meta := map[string]any{}
+	if data, err := json.Marshal(w.state.Meta); err == nil {
+		json.Unmarshal(data, &meta)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, meta); err != nil {
+		return fmt.Errorf("%w: %s guard failed: %v", ErrStepIncomplete, step.Name, err)
+	}
+
+	if strings.TrimSpace(buf.String()) != "true" {
+		return fmt.Errorf("%w: %s guard is not satisfied: %s", ErrStepIncomplete, step.Name, step.Guard)
+	}
+
+	return nil
+}
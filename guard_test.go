@@ -0,0 +1,77 @@
+package swf_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestStepGuard(t *testing.T) {
+	wf := swf.NewWorkflow()
+
+	review := swf.NewStep("review")
+	review.Guard = `{{and .budget_approved (ge .budget 1000.0)}}`
+
+	wf.AddStep(review)
+	wf.AddStep(swf.NewStep("publish"))
+
+	// A missing metadata key does not satisfy the guard
+	err := wf.AdvanceCurrentStep()
+	if !errors.Is(err, swf.ErrStepIncomplete) || !strings.Contains(err.Error(), "budget_approved") {
+		t.Errorf("Expected ErrStepIncomplete for the missing key, got %v", err)
+	}
+
+	wf.SetMeta("budget_approved", true)
+	wf.SetMeta("budget", 500)
+
+	if err := wf.AdvanceCurrentStep(); !errors.Is(err, swf.ErrStepIncomplete) {
+		t.Errorf("Expected ErrStepIncomplete below the budget, got %v", err)
+	}
+
+	if wf.MarkStepAsCompleted("review") {
+		t.Error("Expected MarkStepAsCompleted to fail while the guard is not satisfied")
+	}
+
+	// Numbers compare as float64, whether set as int or restored from JSON
+	wf.SetMeta("budget", 1500)
+
+	if err := wf.AdvanceCurrentStep(); err != nil {
+		t.Fatalf("AdvanceCurrentStep failed: %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "publish" {
+		t.Errorf("Expected current step publish, got %s", wf.GetState().CurrentStepName)
+	}
+}
+
+func TestStepGuardInDefinition(t *testing.T) {
+	definition, err := swf.NewDefinitionFromString(`{"Steps": [
+		{"Name": "review", "Guard": "{{eq .tenant \"acme\"}}"},
+		{"Name": "publish"}
+	]}`)
+	if err != nil {
+		t.Fatalf("NewDefinitionFromString failed: %v", err)
+	}
+
+	wf, err := definition.NewWorkflow()
+	if err != nil {
+		t.Fatalf("NewWorkflow failed: %v", err)
+	}
+
+	wf.SetMeta("tenant", "other")
+	if err := wf.AdvanceCurrentStep(); !errors.Is(err, swf.ErrStepIncomplete) {
+		t.Errorf("Expected ErrStepIncomplete for another tenant, got %v", err)
+	}
+
+	wf.SetMeta("tenant", "acme")
+	if err := wf.AdvanceCurrentStep(); err != nil {
+		t.Errorf("Expected the guard to be satisfied, got %v", err)
+	}
+
+	definition, _ = swf.NewDefinitionFromString(`{"Steps": [{"Name": "review", "Guard": "{{eq .tenant"}]}`)
+	if err := definition.Validate(); err == nil || !strings.Contains(err.Error(), "step guard is invalid: review") {
+		t.Errorf("Expected error for an invalid guard, got %v", err)
+	}
+}
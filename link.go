@@ -0,0 +1,222 @@
+package swf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultLinkPathTemplate is the default path template of action links
+const DefaultLinkPathTemplate = "/{instance}/{step}"
+
+// LinkTokenParameter is the query parameter carrying the signature of action links
+const LinkTokenParameter = "token"
+
+// Link path template placeholders
+const (
+	linkPlaceholderInstance    = "{instance}"
+	linkPlaceholderStep        = "{step}"
+	linkPlaceholderResponsible = "{responsible}"
+)
+
+// LinkBuilder builds the action links to the steps of workflow instances,
+// e.g. to be sent in notifications to the responsible
+type LinkBuilder struct {
+	// BaseURL is prepended to the links, e.g. "https://example.com/workflows"
+	BaseURL string
+
+	// PathTemplate is the path of the links, relative to the BaseURL.
+	// Each placeholder {instance}, {step} and {responsible} must be a whole
+	// path segment, and is replaced with the escaped value.
+	// Defaults to DefaultLinkPathTemplate.
+	PathTemplate string
+
+	// Secret, when set, is used to sign the links with an HMAC token
+	// in the LinkTokenParameter query parameter
+	Secret []byte
+}
+
+// Action is an action link request, dispatched by the ActionRouter
+type Action struct {
+	InstanceID  string
+	StepName    string
+	Responsible string
+}
+
+// ActionHandler handles an action link request
+type ActionHandler func(w http.ResponseWriter, r *http.Request, action *Action)
+
+// Build returns the action link to a step of a workflow instance
+//
+// Business logic:
+// 1. Replace the placeholders of the path template with the escaped values
+// 2. Sign the path, if a secret is configured
+// 3. Prepend the base URL
+func (b *LinkBuilder) Build(instanceID string, step *Step) (string, error) {
+	if step == nil {
+		return "", fmt.Errorf("step is required")
+	}
+
+	segments := b.templateSegments()
+	for i, segment := range segments {
+		switch segment {
+		case linkPlaceholderInstance:
+			if instanceID == "" {
+				return "", fmt.Errorf("instance ID is required by the link template")
+			}
+			segments[i] = url.PathEscape(instanceID)
+		case linkPlaceholderStep:
+			segments[i] = url.PathEscape(step.Name)
+		case linkPlaceholderResponsible:
+			segments[i] = url.PathEscape(step.Responsible)
+		}
+	}
+
+	path := strings.Join(segments, "/")
+	link := strings.TrimSuffix(b.BaseURL, "/") + path
+
+	if len(b.Secret) > 0 {
+		link += "?" + LinkTokenParameter + "=" + signHMAC(b.Secret, path)
+	}
+
+	return link, nil
+}
+
+// Match parses a request path, relative to the base URL path,
+// returning the action when it matches the path template
+func (b *LinkBuilder) Match(escapedPath string) (*Action, bool) {
+	basePath := ""
+	if base, err := url.Parse(b.BaseURL); err == nil {
+		basePath = strings.TrimSuffix(base.EscapedPath(), "/")
+	}
+
+	if !strings.HasPrefix(escapedPath, basePath) {
+		return nil, false
+	}
+	escapedPath = strings.TrimPrefix(escapedPath, basePath)
+
+	templateSegments := b.templateSegments()
+	pathSegments := strings.Split(escapedPath, "/")
+	if len(templateSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	action := &Action{}
+	for i, segment := range templateSegments {
+		value, err := url.PathUnescape(pathSegments[i])
+		if err != nil {
+			return nil, false
+		}
+
+		switch segment {
+		case linkPlaceholderInstance:
+			action.InstanceID = value
+		case linkPlaceholderStep:
+			action.StepName = value
+		case linkPlaceholderResponsible:
+			action.Responsible = value
+		default:
+			if segment != pathSegments[i] {
+				return nil, false
+			}
+			continue
+		}
+
+		if value == "" {
+			return nil, false
+		}
+	}
+
+	return action, true
+}
+
+// Verify checks the token of a request path, relative to the base URL path.
+// Always succeeds if no secret is configured.
+func (b *LinkBuilder) Verify(escapedPath string, token string) bool {
+	if len(b.Secret) == 0 {
+		return true
+	}
+
+	if base, err := url.Parse(b.BaseURL); err == nil {
+		escapedPath = strings.TrimPrefix(escapedPath, strings.TrimSuffix(base.EscapedPath(), "/"))
+	}
+
+	return hmac.Equal([]byte(signHMAC(b.Secret, escapedPath)), []byte(token))
+}
+
+// templateSegments returns the path segments of the template
+func (b *LinkBuilder) templateSegments() []string {
+	template := b.PathTemplate
+	if template == "" {
+		template = DefaultLinkPathTemplate
+	}
+
+	if !strings.HasPrefix(template, "/") {
+		template = "/" + template
+	}
+
+	return strings.Split(template, "/")
+}
+
+// ActionRouter dispatches incoming action links to the handler of their step
+type ActionRouter struct {
+	builder  *LinkBuilder
+	handlers map[string]ActionHandler
+}
+
+var _ http.Handler = (*ActionRouter)(nil)
+
+// NewActionRouter creates a new ActionRouter for the links built by the builder
+func NewActionRouter(builder *LinkBuilder) *ActionRouter {
+	return &ActionRouter{
+		builder:  builder,
+		handlers: make(map[string]ActionHandler),
+	}
+}
+
+// Handle registers the handler of the action links to a step
+func (r *ActionRouter) Handle(stepName string, handler ActionHandler) {
+	r.handlers[stepName] = handler
+}
+
+// ServeHTTP matches the request against the link template, verifies its token
+// and dispatches it to the handler of the step
+//
+// Business logic:
+// 1. Respond 404 Not Found if the path does not match the template
+// 2. Respond 403 Forbidden if the token is missing or invalid
+// 3. Respond 404 Not Found if no handler is registered for the step
+// 4. Call the handler of the step
+func (r *ActionRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := req.URL.EscapedPath()
+
+	action, ok := r.builder.Match(path)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	if !r.builder.Verify(path, req.URL.Query().Get(LinkTokenParameter)) {
+		http.Error(w, "invalid action link token", http.StatusForbidden)
+		return
+	}
+
+	handler, exists := r.handlers[action.StepName]
+	if !exists {
+		http.NotFound(w, req)
+		return
+	}
+
+	handler(w, req, action)
+}
+
+// signHMAC returns the URL safe HMAC-SHA256 signature of the data
+func signHMAC(secret []byte, data string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
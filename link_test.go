@@ -0,0 +1,116 @@
+package swf_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestLinkBuilderBuild(t *testing.T) {
+	step := swf.NewStep("manager approval")
+	step.Responsible = "jane.doe@example.com"
+
+	builder := &swf.LinkBuilder{}
+	link, err := builder.Build("doc/42", step)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if link != "/doc%2F42/manager%20approval" {
+		t.Errorf("Unexpected link %s", link)
+	}
+
+	builder = &swf.LinkBuilder{
+		BaseURL:      "https://example.com/workflows/",
+		PathTemplate: "actions/{responsible}/{instance}/{step}",
+	}
+
+	link, err = builder.Build("42", step)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if link != "https://example.com/workflows/actions/jane.doe@example.com/42/manager%20approval" {
+		t.Errorf("Unexpected link %s", link)
+	}
+
+	if _, err := builder.Build("", step); err == nil {
+		t.Error("Expected error for missing instance ID")
+	}
+
+	if _, err := builder.Build("42", nil); err == nil {
+		t.Error("Expected error for missing step")
+	}
+
+	// Signed links
+	builder.Secret = []byte("secret")
+	link, err = builder.Build("42", step)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if !strings.Contains(link, "?token=") {
+		t.Errorf("Expected signed link, got %s", link)
+	}
+}
+
+func TestActionRouter(t *testing.T) {
+	builder := &swf.LinkBuilder{
+		BaseURL: "https://example.com/workflows",
+		Secret:  []byte("secret"),
+	}
+
+	review := swf.NewStep("review step")
+	publish := swf.NewStep("publish")
+
+	var handled *swf.Action
+	router := swf.NewActionRouter(builder)
+	router.Handle("review step", func(w http.ResponseWriter, r *http.Request, action *swf.Action) {
+		handled = action
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(link string) int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, link, nil))
+		return recorder.Code
+	}
+
+	link, _ := builder.Build("doc/42", review)
+	if code := serve(link); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+
+	if handled == nil || handled.InstanceID != "doc/42" || handled.StepName != "review step" {
+		t.Errorf("Unexpected action %+v", handled)
+	}
+
+	// Tampered links are rejected
+	tampered := strings.Replace(link, "doc%2F42", "doc%2F43", 1)
+	if code := serve(tampered); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for tampered link, got %d", code)
+	}
+
+	unsigned := strings.Split(link, "?")[0]
+	if code := serve(unsigned); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for unsigned link, got %d", code)
+	}
+
+	// Steps without a handler
+	link, _ = builder.Build("42", publish)
+	if code := serve(link); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for step without handler, got %d", code)
+	}
+
+	// Paths not matching the template
+	if code := serve("https://example.com/workflows/42"); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unmatched path, got %d", code)
+	}
+
+	if code := serve("https://example.com/other/42/review%20step"); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for other base path, got %d", code)
+	}
+}
@@ -0,0 +1,40 @@
+package swf
+
+// Listener is notified of the step lifecycle changes of a workflow,
+// e.g. to collect metrics or traces
+type Listener interface {
+	// OnStepStarted is called after a step has become the current step
+	OnStepStarted(w *Workflow, step *Step)
+
+	// OnStepCompleted is called after a step has been marked as completed
+	OnStepCompleted(w *Workflow, step *Step)
+}
+
+// AddListener registers a listener to be notified of step lifecycle changes
+func (w *Workflow) AddListener(listener Listener) {
+	w.listeners = append(w.listeners, listener)
+}
+
+// notifyStepStarted notifies the listeners that a step has started
+func (w *Workflow) notifyStepStarted(stepName string) {
+	step := w.GetStep(stepName)
+	if step == nil {
+		return
+	}
+
+	for _, listener := range w.listeners {
+		listener.OnStepStarted(w, step)
+	}
+}
+
+// notifyStepCompleted notifies the listeners that a step has been completed
+func (w *Workflow) notifyStepCompleted(stepName string) {
+	step := w.GetStep(stepName)
+	if step == nil {
+		return
+	}
+
+	for _, listener := range w.listeners {
+		listener.OnStepCompleted(w, step)
+	}
+}
@@ -0,0 +1,55 @@
+package swf_test
+
+import (
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+// recordingListener records the step lifecycle events it receives
+type recordingListener struct {
+	events []string
+}
+
+func (l *recordingListener) OnStepStarted(w *swf.Workflow, step *swf.Step) {
+	l.events = append(l.events, "started:"+step.Name)
+}
+
+func (l *recordingListener) OnStepCompleted(w *swf.Workflow, step *swf.Step) {
+	l.events = append(l.events, "completed:"+step.Name)
+}
+
+func TestAddListener(t *testing.T) {
+	wf := swf.NewWorkflow()
+	listener := &recordingListener{}
+	wf.AddListener(listener)
+
+	wf.AddStep(swf.NewStep("step1"))
+	wf.AddStep(swf.NewStep("step2"))
+	wf.AddStep(swf.NewStep("step3"))
+
+	wf.SetCurrentStep("step2")
+	wf.MarkStepAsCompleted("step2")
+	wf.SetCurrentStep("step3")
+	wf.MarkStepAsCompleted("step3")
+	wf.MarkStepAsCompleted("step3")
+
+	expected := []string{
+		"started:step1",
+		"completed:step1",
+		"started:step2",
+		"completed:step2",
+		"started:step3",
+		"completed:step3",
+	}
+
+	if len(listener.events) != len(expected) {
+		t.Fatalf("Expected events %v, got %v", expected, listener.events)
+	}
+
+	for i, event := range expected {
+		if listener.events[i] != event {
+			t.Errorf("Expected event %d to be %s, got %s", i, event, listener.events[i])
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package swf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// mermaidIDRegex matches step names usable as Mermaid node IDs
+var mermaidIDRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// VisualizeMermaid returns a Mermaid flowchart representation of the workflow,
+// with the same status colors as Visualize
+func (w *Workflow) VisualizeMermaid() string {
+	builder := strings.Builder{}
+	builder.WriteString("flowchart LR\n")
+
+	ids := mermaidIDs(w.steps)
+	for i, step := range w.steps {
+		id := ids[i]
+
+		label := step.Title
+		if label == "" {
+			label = step.Name
+		}
+
+		builder.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", id, mermaidText(label)))
+	}
+
+	for i := 1; i < len(ids); i++ {
+		builder.WriteString(fmt.Sprintf("    %s --> %s\n", ids[i-1], ids[i]))
+	}
+
+	if len(w.steps) == 0 {
+		return builder.String()
+	}
+
+	builder.WriteString(fmt.Sprintf("    classDef %s fill:%s,color:#ffffff\n", stepStatusCompleted, colorCompleted))
+	builder.WriteString(fmt.Sprintf("    classDef %s fill:%s,color:#ffffff\n", stepStatusCurrent, colorCurrent))
+	builder.WriteString(fmt.Sprintf("    classDef %s fill:%s\n", stepStatusPending, colorPending))
+
+	for i, step := range w.steps {
+		builder.WriteString(fmt.Sprintf("    class %s %s\n", ids[i], w.stepStatus(step)))
+	}
+
+	return builder.String()
+}
+
+// mermaidIDs returns the Mermaid node IDs of the steps: their names, or for
+// names unusable as IDs "step<position>", suffixed until it is not the name
+// of another step
+func mermaidIDs(steps []*Step) []string {
+	valid := func(name string) bool {
+		return mermaidIDRegex.MatchString(name) && name != "end"
+	}
+
+	used := map[string]bool{}
+	for _, step := range steps {
+		if valid(step.Name) {
+			used[step.Name] = true
+		}
+	}
+
+	ids := make([]string, 0, len(steps))
+	for i, step := range steps {
+		if valid(step.Name) {
+			ids = append(ids, step.Name)
+			continue
+		}
+
+		id := fmt.Sprintf("step%d", i+1)
+		for suffix := 2; used[id]; suffix++ {
+			id = fmt.Sprintf("step%d_%d", i+1, suffix)
+		}
+		used[id] = true
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// mermaidText escapes text for use in a Mermaid node label
+func mermaidText(text string) string {
+	text = strings.ReplaceAll(text, "\r", "")
+	text = strings.ReplaceAll(text, "\n", "<br>")
+	return strings.ReplaceAll(text, `"`, "#quot;")
+}
@@ -0,0 +1,72 @@
+package swf_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestVisualizeMermaid(t *testing.T) {
+	wf := swf.NewWorkflow()
+
+	step1 := swf.NewStep("review")
+	step1.Title = `Review "draft"`
+	wf.AddStep(step1)
+	wf.AddStep(swf.NewStep("manager approval"))
+	wf.AddStep(swf.NewStep("publish"))
+
+	wf.SetCurrentStep("manager approval")
+
+	mermaid := wf.VisualizeMermaid()
+
+	expected := []string{
+		"flowchart LR\n",
+		`review["Review #quot;draft#quot;"]`,
+		`step2["manager approval"]`,
+		"review --> step2",
+		"step2 --> publish",
+		"class review completed",
+		"class step2 current",
+		"class publish pending",
+	}
+
+	for _, fragment := range expected {
+		if !strings.Contains(mermaid, fragment) {
+			t.Errorf("Expected Mermaid to contain %s, got %s", fragment, mermaid)
+		}
+	}
+}
+
+func TestVisualizeMermaidEmpty(t *testing.T) {
+	mermaid := swf.NewWorkflow().VisualizeMermaid()
+	if mermaid != "flowchart LR\n" {
+		t.Errorf("Expected empty flowchart, got %s", mermaid)
+	}
+}
+
+func TestVisualizeMermaidIDClash(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("bad-name"))
+	wf.AddStep(swf.NewStep("step1"))
+	wf.AddStep(swf.NewStep("end"))
+	wf.AddStep(swf.NewStep("step3_2"))
+
+	mermaid := wf.VisualizeMermaid()
+
+	expected := []string{
+		`step1_2["bad-name"]`,
+		`step1["step1"]`,
+		`step3["end"]`,
+		`step3_2["step3_2"]`,
+		"step1_2 --> step1",
+		"step1 --> step3",
+		"step3 --> step3_2",
+	}
+
+	for _, fragment := range expected {
+		if !strings.Contains(mermaid, fragment) {
+			t.Errorf("Expected Mermaid to contain %s, got %s", fragment, mermaid)
+		}
+	}
+}
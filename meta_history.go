@@ -0,0 +1,126 @@
+package swf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// MetaChange is a recorded change of a step metadata value
+type MetaChange struct {
+	Step  string
+	Key   string
+	Old   any `json:",omitempty"`
+	New   any `json:",omitempty"`
+	Actor string
+	Time  string
+}
+
+// String describes the change, e.g. "amount changed from 100 to 120 by Jane"
+func (c *MetaChange) String() string {
+	text := fmt.Sprintf("%s changed from %v to %v", c.Key, c.Old, c.New)
+	if c.Old == nil {
+		text = fmt.Sprintf("%s set to %v", c.Key, c.New)
+	}
+
+	if c.Actor != "" {
+		text += " by " + c.Actor
+	}
+
+	return text
+}
+
+// SetStepMetaBy sets step metadata, recording the change with the actor
+// making it in the metadata history
+func (w *Workflow) SetStepMetaBy(step any, key string, value any, actor string) error {
+	stepName, _, err := w.stepDetails(step)
+	if err != nil {
+		return err
+	}
+
+	w.setStepMeta(stepName, key, value, actor)
+	return nil
+}
+
+// GetStepMetaHistory returns the recorded changes of a step metadata key,
+// oldest first
+func (w *Workflow) GetStepMetaHistory(step any, key string) []*MetaChange {
+	stepName, err := stepName(step)
+	if err != nil {
+		return []*MetaChange{}
+	}
+
+	return lo.Filter(w.state.MetaHistory, func(change *MetaChange, index int) bool {
+		return change.Step == stepName && change.Key == key
+	})
+}
+
+// setStepMeta sets step metadata, recording the change in the metadata history
+//
+// Business logic:
+// 1. Skip values equal to the current value, comparing numbers by value, which are not changes
+// 2. Record the previous and new value, the actor and the time
+// 3. Set the value
+func (w *Workflow) setStepMeta(stepName string, key string, value any, actor string) {
+	details := w.state.StepDetails[stepName]
+	if details.Meta == nil {
+		details.Meta = make(map[string]any)
+	}
+	meta := details.Meta
+
+	old, exists := meta[key]
+	if exists && metaEqual(old, value) {
+		return
+	}
+
+	w.state.MetaHistory = append(w.state.MetaHistory, &MetaChange{
+		Step:  stepName,
+		Key:   key,
+		Old:   old,
+		New:   value,
+		Actor: actor,
+		Time:  time.Now().Format(time.RFC3339),
+	})
+
+	meta[key] = value
+}
+
+// deleteStepMeta deletes step metadata, recording the change in the metadata history
+func (w *Workflow) deleteStepMeta(stepName string, key string, actor string) {
+	details := w.state.StepDetails[stepName]
+
+	old, exists := details.Meta[key]
+	if !exists {
+		return
+	}
+
+	w.state.MetaHistory = append(w.state.MetaHistory, &MetaChange{
+		Step:  stepName,
+		Key:   key,
+		Old:   old,
+		Actor: actor,
+		Time:  time.Now().Format(time.RFC3339),
+	})
+
+	delete(details.Meta, key)
+}
+
+// metaEqual checks if two metadata values are equal once serialized, e.g. a
+// float64(100) restored from JSON and an int 100 set afterwards
+func metaEqual(a any, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	return bytes.Equal(aJSON, bJSON)
+}
@@ -0,0 +1,112 @@
+package swf_test
+
+import (
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestStepMetaHistory(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("review"))
+	wf.AddStep(swf.NewStep("approve"))
+
+	wf.SetStepMeta("review", "amount", 100)
+	wf.SetStepMetaBy("review", "amount", 120, "Jane")
+	wf.SetStepMetaBy("review", "amount", 120, "John") // unchanged, not recorded
+	wf.SetStepMetaBy("review", "comment", "ok", "Jane")
+	wf.SetStepMetaBy("approve", "amount", 1, "Jane")
+
+	if wf.GetStepMeta("review", "amount") != 120 {
+		t.Errorf("Expected amount 120, got %v", wf.GetStepMeta("review", "amount"))
+	}
+
+	history := wf.GetStepMetaHistory("review", "amount")
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 changes, got %d", len(history))
+	}
+
+	if history[0].Old != nil || history[0].New != 100 || history[0].Actor != "" {
+		t.Errorf("Expected first change to set 100, got %+v", history[0])
+	}
+
+	if history[1].Old != 100 || history[1].New != 120 || history[1].Actor != "Jane" || history[1].Time == "" {
+		t.Errorf("Expected second change from 100 to 120 by Jane, got %+v", history[1])
+	}
+
+	if history[0].String() != "amount set to 100" {
+		t.Errorf("Expected 'amount set to 100', got %s", history[0].String())
+	}
+
+	if history[1].String() != "amount changed from 100 to 120 by Jane" {
+		t.Errorf("Expected 'amount changed from 100 to 120 by Jane', got %s", history[1].String())
+	}
+
+	// The history survives serialization
+	str, err := wf.ToString()
+	if err != nil {
+		t.Fatalf("ToString failed: %v", err)
+	}
+
+	restored := swf.NewWorkflow()
+	restored.FromString(str)
+
+	restoredHistory := restored.GetStepMetaHistory("review", "amount")
+	if len(restoredHistory) != 2 || restoredHistory[1].Actor != "Jane" {
+		t.Errorf("Expected history to survive serialization, got %v", restoredHistory)
+	}
+
+	if len(wf.GetStepMetaHistory("review", "missing")) != 0 {
+		t.Error("Expected no history for a key never set")
+	}
+}
+
+func TestSubmitStepFormRecordsHistory(t *testing.T) {
+	wf := swf.NewWorkflow()
+
+	invoice := swf.NewStep("invoice")
+	invoice.Form = &swf.FormSchema{Fields: []*swf.FormField{{Name: "amount", Type: swf.FormFieldTypeNumber}}}
+	wf.AddStep(invoice)
+
+	wf.SubmitStepForm("invoice", map[string]any{"amount": 100})
+	wf.SubmitStepForm("invoice", map[string]any{"amount": 120})
+
+	history := wf.GetStepMetaHistory("invoice", "amount")
+	if len(history) != 2 || history[1].Old != float64(100) || history[1].New != float64(120) {
+		t.Errorf("Expected form submissions to be recorded, got %v", history)
+	}
+}
+
+func TestStepMetaHistoryAfterRoundTrip(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("review"))
+	wf.AddStep(swf.NewStep("approve"))
+
+	wf.SetStepMetaBy("review", "amount", 100, "Jane")
+
+	str, err := wf.ToString()
+	if err != nil {
+		t.Fatalf("ToString failed: %v", err)
+	}
+
+	restored := swf.NewWorkflow()
+	restored.AddStep(swf.NewStep("review"))
+	restored.AddStep(swf.NewStep("approve"))
+	if err := restored.FromString(str); err != nil {
+		t.Fatalf("FromString failed: %v", err)
+	}
+
+	// The restored amount is a float64, the new one an int
+	restored.SetStepMetaBy("review", "amount", 100, "John")
+
+	history := restored.GetStepMetaHistory("review", "amount")
+	if len(history) != 1 {
+		t.Errorf("Expected the unchanged amount not to be recorded, got %v", history)
+	}
+
+	restored.SetStepMetaBy("review", "amount", "100", "John")
+
+	if len(restored.GetStepMetaHistory("review", "amount")) != 2 {
+		t.Error("Expected a string to be a change from a number")
+	}
+}
@@ -0,0 +1,234 @@
+// Package metrics exposes workflow metrics in the Prometheus text exposition format.
+//
+// Metrics implements swf.Listener, counting the transitions per step and
+// observing step durations, and serves them as an http.Handler:
+//
+//	m := metrics.New()
+//	wf.AddListener(m)
+//	http.Handle("/metrics", m)
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dracory/swf"
+	"github.com/samber/lo"
+)
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the default step duration histogram buckets, in seconds,
+// ranging from a minute to a month, as human steps take minutes to weeks
+var DefaultBuckets = []float64{
+	60,      // 1 minute
+	300,     // 5 minutes
+	900,     // 15 minutes
+	3600,    // 1 hour
+	14400,   // 4 hours
+	28800,   // 8 hours
+	86400,   // 1 day
+	259200,  // 3 days
+	604800,  // 1 week
+	2592000, // 30 days
+}
+
+// InstanceSource returns the states of the workflow instances
+// to count per current step, e.g. loaded from a database
+type InstanceSource func() ([]*swf.WorkflowState, error)
+
+// histogram is a cumulative histogram of observations
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// Metrics collects workflow metrics
+type Metrics struct {
+	mu          sync.Mutex
+	buckets     []float64
+	transitions map[string]uint64
+	durations   map[string]*histogram
+	source      InstanceSource
+}
+
+var _ swf.Listener = (*Metrics)(nil)
+var _ http.Handler = (*Metrics)(nil)
+
+// New creates a new Metrics with the default buckets
+func New() *Metrics {
+	return NewWithBuckets(DefaultBuckets)
+}
+
+// NewWithBuckets creates a new Metrics with the given duration buckets, in seconds
+func NewWithBuckets(buckets []float64) *Metrics {
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+
+	return &Metrics{
+		buckets:     lo.Uniq(sorted),
+		transitions: map[string]uint64{},
+		durations:   map[string]*histogram{},
+	}
+}
+
+// SetInstanceSource sets the source of the instances counted
+// by the swf_instances gauge. Without a source the gauge is not exposed.
+func (m *Metrics) SetInstanceSource(source InstanceSource) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.source = source
+}
+
+// OnStepStarted counts a transition into the step
+func (m *Metrics) OnStepStarted(w *swf.Workflow, step *swf.Step) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transitions[step.Name]++
+}
+
+// OnStepCompleted observes the duration of the completed step
+func (m *Metrics) OnStepCompleted(w *swf.Workflow, step *swf.Step) {
+	details := w.GetState().StepDetails[step.Name]
+	if details == nil {
+		return
+	}
+
+	duration, ok := details.Duration()
+	if !ok {
+		return
+	}
+
+	m.Observe(step.Name, duration.Seconds())
+}
+
+// Observe records a step duration, in seconds
+func (m *Metrics) Observe(stepName string, seconds float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := m.durations[stepName]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.durations[stepName] = h
+	}
+
+	for i, bucket := range m.buckets {
+		if seconds <= bucket {
+			h.counts[i]++
+			break
+		}
+	}
+
+	h.sum += seconds
+	h.count++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	buf := new(bytes.Buffer)
+	if err := m.Write(buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(buf.Bytes())
+}
+
+// Write writes the metrics in the Prometheus text exposition format
+func (m *Metrics) Write(w io.Writer) error {
+	m.mu.Lock()
+	source := m.source
+	transitions := lo.Assign(m.transitions)
+	durations := make(map[string]histogram, len(m.durations))
+	for name, h := range m.durations {
+		durations[name] = histogram{counts: append([]uint64{}, h.counts...), sum: h.sum, count: h.count}
+	}
+	m.mu.Unlock()
+
+	// Count the instances outside the lock, as the source may be slow
+	instances := map[string]uint64{}
+	if source != nil {
+		states, err := source()
+		if err != nil {
+			return fmt.Errorf("loading instances: %w", err)
+		}
+
+		for _, state := range states {
+			if state == nil || state.CurrentStepName == "" {
+				continue
+			}
+
+			// Finished instances are no longer in a step
+			details := state.StepDetails[state.CurrentStepName]
+			if details != nil && details.Completed != "" {
+				continue
+			}
+
+			instances[state.CurrentStepName]++
+		}
+	}
+
+	b := &strings.Builder{}
+
+	b.WriteString("# HELP swf_step_transitions_total Number of transitions into a step.\n")
+	b.WriteString("# TYPE swf_step_transitions_total counter\n")
+	for _, name := range sortedKeys(transitions) {
+		fmt.Fprintf(b, "swf_step_transitions_total{step=\"%s\"} %d\n", escapeLabel(name), transitions[name])
+	}
+
+	b.WriteString("# HELP swf_step_duration_seconds Time spent on a step, from start to completion.\n")
+	b.WriteString("# TYPE swf_step_duration_seconds histogram\n")
+	for _, name := range sortedKeys(durations) {
+		h := durations[name]
+		label := escapeLabel(name)
+
+		cumulative := uint64(0)
+		for i, bucket := range m.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(b, "swf_step_duration_seconds_bucket{step=\"%s\",le=\"%s\"} %d\n", label, formatFloat(bucket), cumulative)
+		}
+		fmt.Fprintf(b, "swf_step_duration_seconds_bucket{step=\"%s\",le=\"+Inf\"} %d\n", label, h.count)
+		fmt.Fprintf(b, "swf_step_duration_seconds_sum{step=\"%s\"} %s\n", label, formatFloat(h.sum))
+		fmt.Fprintf(b, "swf_step_duration_seconds_count{step=\"%s\"} %d\n", label, h.count)
+	}
+
+	if source != nil {
+		b.WriteString("# HELP swf_instances Number of unfinished instances per current step.\n")
+		b.WriteString("# TYPE swf_instances gauge\n")
+		for _, name := range sortedKeys(instances) {
+			fmt.Fprintf(b, "swf_instances{step=\"%s\"} %d\n", escapeLabel(name), instances[name])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sortedKeys returns the keys of a map in ascending order
+func sortedKeys[V any](values map[string]V) []string {
+	keys := lo.Keys(values)
+	sort.Strings(keys)
+	return keys
+}
+
+// escapeLabel escapes a label value as required by the exposition format
+func escapeLabel(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+// formatFloat formats a sample value as required by the exposition format
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
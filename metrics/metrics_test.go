@@ -0,0 +1,114 @@
+package metrics_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dracory/swf"
+	"github.com/dracory/swf/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.NewWithBuckets([]float64{3600, 60})
+
+	wf := swf.NewWorkflow()
+	wf.AddListener(m)
+	wf.AddStep(swf.NewStep("review"))
+	wf.AddStep(swf.NewStep("approve"))
+
+	// Pretend the review started 30 minutes ago
+	wf.GetState().StepDetails["review"].Started = time.Now().Add(-30 * time.Minute).Format(time.RFC3339)
+	wf.SetCurrentStep("approve")
+
+	m.Observe("approve", 7200)
+
+	m.SetInstanceSource(func() ([]*swf.WorkflowState, error) {
+		return []*swf.WorkflowState{
+			wf.GetState(),
+			{CurrentStepName: "approve", StepDetails: map[string]*swf.StepDetails{}},
+			{CurrentStepName: "review", StepDetails: map[string]*swf.StepDetails{
+				"review": {Started: "2024-01-01T09:00:00Z", Completed: "2024-01-01T10:00:00Z"},
+			}},
+		}, nil
+	})
+
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", recorder.Code)
+	}
+
+	if recorder.Header().Get("Content-Type") != metrics.ContentType {
+		t.Errorf("Unexpected content type %s", recorder.Header().Get("Content-Type"))
+	}
+
+	body := recorder.Body.String()
+
+	expected := []string{
+		"# TYPE swf_step_transitions_total counter\n",
+		`swf_step_transitions_total{step="approve"} 1` + "\n",
+		`swf_step_transitions_total{step="review"} 1` + "\n",
+		"# TYPE swf_step_duration_seconds histogram\n",
+		`swf_step_duration_seconds_bucket{step="review",le="60"} 0` + "\n",
+		`swf_step_duration_seconds_bucket{step="review",le="3600"} 1` + "\n",
+		`swf_step_duration_seconds_bucket{step="review",le="+Inf"} 1` + "\n",
+		`swf_step_duration_seconds_count{step="review"} 1` + "\n",
+		`swf_step_duration_seconds_bucket{step="approve",le="3600"} 0` + "\n",
+		`swf_step_duration_seconds_bucket{step="approve",le="+Inf"} 1` + "\n",
+		`swf_step_duration_seconds_sum{step="approve"} 7200` + "\n",
+		"# TYPE swf_instances gauge\n",
+		`swf_instances{step="approve"} 2` + "\n",
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", line, body)
+		}
+	}
+
+	// Finished instances are not counted
+	if strings.Contains(body, `swf_instances{step="review"}`) {
+		t.Error("Expected finished instance not to be counted")
+	}
+}
+
+func TestMetricsWithoutSource(t *testing.T) {
+	m := metrics.New()
+
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if strings.Contains(recorder.Body.String(), "swf_instances") {
+		t.Error("Expected no instances gauge without a source")
+	}
+
+	m.SetInstanceSource(func() ([]*swf.WorkflowState, error) {
+		return nil, errors.New("database down")
+	})
+
+	recorder = httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", recorder.Code)
+	}
+}
+
+func TestMetricsEscapesLabels(t *testing.T) {
+	m := metrics.New()
+	m.Observe("step \"one\"\n", 1)
+
+	builder := &strings.Builder{}
+	if err := m.Write(builder); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if !strings.Contains(builder.String(), `step="step \"one\"\n"`) {
+		t.Errorf("Expected escaped label, got %s", builder.String())
+	}
+}
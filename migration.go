@@ -0,0 +1,278 @@
+package swf
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// Kinds of migration operations
+const (
+	MigrationRenameStep = "rename_step"
+	MigrationRemoveStep = "remove_step"
+	MigrationInsertStep = "insert_step"
+)
+
+// MigrationOperation is a change of the steps between two definition versions
+type MigrationOperation struct {
+	// Kind is one of the Migration constants
+	Kind string
+
+	// Step is the name of the step concerned
+	Step string
+
+	// NewName is the new name of a renamed step
+	NewName string `json:",omitempty"`
+}
+
+// RenameStep returns an operation renaming a step
+func RenameStep(oldName string, newName string) MigrationOperation {
+	return MigrationOperation{Kind: MigrationRenameStep, Step: oldName, NewName: newName}
+}
+
+// RemoveStep returns an operation removing a step
+func RemoveStep(name string) MigrationOperation {
+	return MigrationOperation{Kind: MigrationRemoveStep, Step: name}
+}
+
+// InsertStep returns an operation inserting a step.
+// Its position is given by the target workflow definition.
+func InsertStep(name string) MigrationOperation {
+	return MigrationOperation{Kind: MigrationInsertStep, Step: name}
+}
+
+// Migration lists the operations upgrading instances from a definition version to the next
+type Migration struct {
+	From       string
+	To         string
+	Operations []MigrationOperation
+}
+
+// MigrationReport describes the migration of an instance
+type MigrationReport struct {
+	// From and To are the versions of the state before and after the migration
+	From string
+	To   string
+
+	// Changes describes the changes made to the state, in order
+	Changes []string
+
+	// State is the migrated state
+	State *WorkflowState
+}
+
+// Migrator upgrades instances of old definition versions
+// with registered step renames, removals and insertions
+//
+//	migrator := swf.NewMigrator()
+//	migrator.Register("1", "2", swf.RenameStep("review", "legal_review"), swf.InsertStep("sign"))
+//	migrator.Register("2", "3", swf.RemoveStep("archive"))
+//
+//	report, err := migrator.DryRun(wf, state) // wf is the version 3 workflow
+//	report, err = migrator.Upgrade(wf, state)
+type Migrator struct {
+	migrations []*Migration
+}
+
+// NewMigrator creates a new Migrator
+func NewMigrator() *Migrator {
+	return &Migrator{migrations: []*Migration{}}
+}
+
+// Register registers the operations upgrading instances from a version to the next
+func (m *Migrator) Register(from string, to string, operations ...MigrationOperation) error {
+	if from == to {
+		return fmt.Errorf("migration from and to the same version: %s", from)
+	}
+
+	if m.find(from) != nil {
+		return fmt.Errorf("migration from version %s already registered", from)
+	}
+
+	m.migrations = append(m.migrations, &Migration{
+		From:       from,
+		To:         to,
+		Operations: operations,
+	})
+
+	return nil
+}
+
+// DryRun migrates a copy of the state to the version of the workflow,
+// without changing the state or the workflow
+//
+// Business logic:
+// 1. Find the chain of migrations from the state version to the workflow version
+// 2. Apply the operations of each migration to a copy of the state
+// 3. Move the current step to the first pending step if it was removed
+// 4. Check the migrated state is valid for the workflow, returning a StateError if not
+func (m *Migrator) DryRun(w *Workflow, state *WorkflowState) (*MigrationReport, error) {
+	chain, err := m.chain(state.Version, w.version)
+	if err != nil {
+		return nil, err
+	}
+
+	migrated, err := copyState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MigrationReport{
+		From:    state.Version,
+		To:      w.version,
+		Changes: []string{},
+		State:   migrated,
+	}
+
+	for _, migration := range chain {
+		for _, operation := range migration.Operations {
+			change, err := applyMigrationOperation(migrated, operation)
+			if err != nil {
+				return report, fmt.Errorf("migration %s to %s: %w", migration.From, migration.To, err)
+			}
+			report.Changes = append(report.Changes, change)
+		}
+		migrated.Version = migration.To
+	}
+
+	if migrated.CurrentStepName == "" && len(w.steps) > 0 {
+		next := lo.FindOrElse(w.steps, w.steps[len(w.steps)-1], func(step *Step) bool {
+			details := migrated.StepDetails[step.Name]
+			return details == nil || details.Completed == ""
+		})
+
+		migrated.CurrentStepName = next.Name
+		migrated.History = append(migrated.History, next.Name)
+		if details := migrated.StepDetails[next.Name]; details != nil {
+			details.Started = time.Now().Format(time.RFC3339)
+		}
+		report.Changes = append(report.Changes, fmt.Sprintf("current step moved to %s", next.Name))
+	}
+
+	if issues := w.ValidateState(migrated); len(issues) > 0 {
+		return report, &StateError{Issues: issues}
+	}
+
+	return report, nil
+}
+
+// Upgrade migrates the state to the version of the workflow,
+// and sets the migrated state on the workflow
+func (m *Migrator) Upgrade(w *Workflow, state *WorkflowState) (*MigrationReport, error) {
+	report, err := m.DryRun(w, state)
+	if err != nil {
+		return report, err
+	}
+
+	w.SetState(report.State)
+	return report, nil
+}
+
+// find returns the migration from a version, or nil
+func (m *Migrator) find(from string) *Migration {
+	migration, _ := lo.Find(m.migrations, func(migration *Migration) bool {
+		return migration.From == from
+	})
+	return migration
+}
+
+// chain returns the migrations to apply to upgrade from a version to another
+func (m *Migrator) chain(from string, to string) ([]*Migration, error) {
+	chain := []*Migration{}
+	visited := map[string]bool{}
+
+	for version := from; version != to; {
+		if visited[version] {
+			return nil, fmt.Errorf("migration cycle at version %s", version)
+		}
+		visited[version] = true
+
+		migration := m.find(version)
+		if migration == nil {
+			return nil, fmt.Errorf("%w: from version %q to %q", ErrMigrationNotFound, from, to)
+		}
+
+		chain = append(chain, migration)
+		version = migration.To
+	}
+
+	return chain, nil
+}
+
+// applyMigrationOperation applies an operation to a state,
+// returning the description of the change
+func applyMigrationOperation(state *WorkflowState, operation MigrationOperation) (string, error) {
+	switch operation.Kind {
+	case MigrationRenameStep:
+		details := state.StepDetails[operation.Step]
+		if details == nil {
+			return "", fmt.Errorf("%w: %s", ErrStepNotFound, operation.Step)
+		}
+
+		if state.StepDetails[operation.NewName] != nil {
+			return "", fmt.Errorf("step already exists: %s", operation.NewName)
+		}
+
+		delete(state.StepDetails, operation.Step)
+		state.StepDetails[operation.NewName] = details
+
+		if state.CurrentStepName == operation.Step {
+			state.CurrentStepName = operation.NewName
+		}
+
+		state.History = lo.Map(state.History, func(name string, index int) string {
+			if name == operation.Step {
+				return operation.NewName
+			}
+			return name
+		})
+
+		for _, change := range state.MetaHistory {
+			if change.Step == operation.Step {
+				change.Step = operation.NewName
+			}
+		}
+
+		return fmt.Sprintf("step %s renamed to %s", operation.Step, operation.NewName), nil
+	case MigrationRemoveStep:
+		if state.StepDetails[operation.Step] == nil {
+			return "", fmt.Errorf("%w: %s", ErrStepNotFound, operation.Step)
+		}
+
+		delete(state.StepDetails, operation.Step)
+
+		if state.CurrentStepName == operation.Step {
+			state.CurrentStepName = ""
+		}
+
+		state.History = lo.Without(state.History, operation.Step)
+
+		state.MetaHistory = lo.Filter(state.MetaHistory, func(change *MetaChange, index int) bool {
+			return change.Step != operation.Step
+		})
+
+		return fmt.Sprintf("step %s removed", operation.Step), nil
+	case MigrationInsertStep:
+		if state.StepDetails[operation.Step] != nil {
+			return "", fmt.Errorf("step already exists: %s", operation.Step)
+		}
+
+		state.StepDetails[operation.Step] = &StepDetails{Meta: make(map[string]any)}
+
+		return fmt.Sprintf("step %s inserted", operation.Step), nil
+	default:
+		return "", fmt.Errorf("unknown migration operation: %s", operation.Kind)
+	}
+}
+
+// copyState returns a deep copy of a state
+func copyState(state *WorkflowState) (*WorkflowState, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseState(string(data))
+}
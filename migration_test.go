@@ -0,0 +1,140 @@
+package swf_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func versionedWorkflow(t *testing.T, version string, names ...string) *swf.Workflow {
+	t.Helper()
+
+	definition := &swf.Definition{Version: version}
+	for _, name := range names {
+		definition.Steps = append(definition.Steps, swf.NewStep(name))
+	}
+
+	wf, err := definition.NewWorkflow()
+	if err != nil {
+		t.Fatalf("NewWorkflow failed: %v", err)
+	}
+	return wf
+}
+
+func TestDefinitionVersion(t *testing.T) {
+	wf := versionedWorkflow(t, "2", "review", "approve")
+
+	if wf.GetVersion() != "2" || wf.GetState().Version != "2" {
+		t.Errorf("Expected version 2, got %s %s", wf.GetVersion(), wf.GetState().Version)
+	}
+
+	if wf.GetDefinition().Version != "2" {
+		t.Errorf("Expected definition version 2, got %s", wf.GetDefinition().Version)
+	}
+
+	// States of another version must be migrated before loading
+	old := versionedWorkflow(t, "1", "review", "approve")
+	str, _ := old.ToString()
+
+	err := wf.FromString(str)
+	if !errors.Is(err, swf.ErrInvalidState) || !strings.Contains(err.Error(), "migrate it first") {
+		t.Errorf("Expected version mismatch, got %v", err)
+	}
+}
+
+func TestMigratorUpgrade(t *testing.T) {
+	old := versionedWorkflow(t, "1", "review", "archive", "publish")
+	old.SetStepMeta("review", "amount", 100)
+	old.AdvanceCurrentStep()
+
+	migrator := swf.NewMigrator()
+	migrator.Register("1", "2", swf.RenameStep("review", "legal_review"), swf.InsertStep("sign"))
+	migrator.Register("2", "3", swf.RemoveStep("archive"))
+
+	wf := versionedWorkflow(t, "3", "legal_review", "sign", "publish")
+
+	// The dry run does not change the state or the workflow
+	before := wf.GetState()
+	report, err := migrator.DryRun(wf, old.GetState())
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+
+	if wf.GetState() != before || old.GetState().StepDetails["review"] == nil {
+		t.Error("Expected the dry run not to change the states")
+	}
+
+	expected := []string{
+		"step review renamed to legal_review",
+		"step sign inserted",
+		"step archive removed",
+		"current step moved to sign",
+	}
+
+	if strings.Join(report.Changes, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected changes %v, got %v", expected, report.Changes)
+	}
+
+	if report.From != "1" || report.To != "3" || report.State.Version != "3" {
+		t.Errorf("Expected migration from 1 to 3, got %s %s %s", report.From, report.To, report.State.Version)
+	}
+
+	if _, err := migrator.Upgrade(wf, old.GetState()); err != nil {
+		t.Fatalf("Upgrade failed: %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "sign" {
+		t.Errorf("Expected current step sign, got %s", wf.GetState().CurrentStepName)
+	}
+
+	if wf.GetStepMeta("legal_review", "amount") != float64(100) {
+		t.Errorf("Expected meta of the renamed step, got %v", wf.GetStepMeta("legal_review", "amount"))
+	}
+
+	if len(wf.GetStepMetaHistory("legal_review", "amount")) != 1 {
+		t.Error("Expected the meta history to follow the renamed step")
+	}
+
+	if strings.Join(wf.GetState().History, ",") != "legal_review,sign" {
+		t.Errorf("Expected history legal_review,sign, got %v", wf.GetState().History)
+	}
+
+	if !wf.IsStepComplete("legal_review") || wf.IsStepComplete("sign") {
+		t.Error("Expected legal_review completed and sign pending")
+	}
+}
+
+func TestMigratorErrors(t *testing.T) {
+	old := versionedWorkflow(t, "1", "review", "approve")
+
+	migrator := swf.NewMigrator()
+	if err := migrator.Register("1", "1"); err == nil {
+		t.Error("Expected error for a migration to the same version")
+	}
+
+	migrator.Register("1", "2", swf.RenameStep("missing", "other"))
+	if err := migrator.Register("1", "3"); err == nil {
+		t.Error("Expected error for a second migration from the same version")
+	}
+
+	_, err := migrator.DryRun(versionedWorkflow(t, "2", "review", "approve"), old.GetState())
+	if !errors.Is(err, swf.ErrStepNotFound) {
+		t.Errorf("Expected ErrStepNotFound, got %v", err)
+	}
+
+	_, err = migrator.DryRun(versionedWorkflow(t, "5", "review", "approve"), old.GetState())
+	if !errors.Is(err, swf.ErrMigrationNotFound) {
+		t.Errorf("Expected ErrMigrationNotFound, got %v", err)
+	}
+
+	// The migrated state must match the workflow
+	incomplete := swf.NewMigrator()
+	incomplete.Register("1", "2")
+
+	_, err = incomplete.DryRun(versionedWorkflow(t, "2", "review", "approve", "publish"), old.GetState())
+	if !errors.Is(err, swf.ErrInvalidState) {
+		t.Errorf("Expected ErrInvalidState for a missing insertion, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package swf
+
+import (
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// VisualizePlantUML returns a PlantUML activity diagram of the workflow
+//
+// Business logic:
+// 1. Each step is an activity, colored by its status (as in Visualize)
+// 2. Activities are grouped in swimlanes by Step.Responsible
+// 3. Step descriptions are rendered as notes next to the activities
+func (w *Workflow) VisualizePlantUML() string {
+	builder := strings.Builder{}
+	builder.WriteString("@startuml\n")
+
+	// Declare swimlanes upfront, so their order follows the steps
+	lanes := []string{}
+	for _, step := range w.steps {
+		lane := plantUMLLane(step.Responsible)
+		if !lo.Contains(lanes, lane) {
+			lanes = append(lanes, lane)
+		}
+	}
+
+	for _, lane := range lanes {
+		builder.WriteString("|" + lane + "|\n")
+	}
+
+	currentLane := ""
+	for i, step := range w.steps {
+		lane := plantUMLLane(step.Responsible)
+		if lane != currentLane {
+			builder.WriteString("|" + lane + "|\n")
+			currentLane = lane
+		}
+
+		if i == 0 {
+			builder.WriteString("start\n")
+		}
+
+		label := step.Title
+		if label == "" {
+			label = step.Name
+		}
+
+		builder.WriteString(stepStatusColor(w.stepStatus(step)) + ":" + plantUMLText(label) + ";\n")
+
+		if step.Description != "" {
+			builder.WriteString("note right\n")
+			for _, line := range strings.Split(step.Description, "\n") {
+				line = strings.TrimSpace(line)
+				if strings.EqualFold(line, "end note") {
+					line = "~" + line
+				}
+				builder.WriteString("  " + line + "\n")
+			}
+			builder.WriteString("end note\n")
+		}
+	}
+
+	if len(w.steps) == 0 {
+		builder.WriteString("start\n")
+	}
+
+	builder.WriteString("stop\n")
+	builder.WriteString("@enduml\n")
+
+	return builder.String()
+}
+
+// plantUMLLane returns a swimlane name usable in PlantUML
+func plantUMLLane(responsible string) string {
+	lane := strings.TrimSpace(strings.ReplaceAll(responsible, "|", "/"))
+	if lane == "" {
+		return "Unassigned"
+	}
+	return lane
+}
+
+// plantUMLText escapes text for use in a PlantUML activity label
+func plantUMLText(text string) string {
+	text = strings.ReplaceAll(text, "\r", "")
+	text = strings.ReplaceAll(text, "\n", "\\n")
+	return strings.ReplaceAll(text, ";", ",")
+}
@@ -0,0 +1,53 @@
+package swf_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestVisualizePlantUML(t *testing.T) {
+	wf := swf.NewWorkflow()
+
+	step1 := swf.NewStep("step1")
+	step1.Title = "Document Review"
+	step1.Description = "Review the submitted document"
+	step1.Responsible = "editor"
+
+	step2 := swf.NewStep("step2")
+	step2.Title = "Manager Approval"
+	step2.Responsible = "manager"
+
+	step3 := swf.NewStep("step3")
+	step3.Title = "Publish; notify"
+	step3.Responsible = "editor"
+
+	wf.AddStep(step1)
+	wf.AddStep(step2)
+	wf.AddStep(step3)
+	wf.SetCurrentStep(step2)
+
+	uml := wf.VisualizePlantUML()
+
+	expected := []string{
+		"@startuml\n|editor|\n|manager|\n|editor|\nstart\n",
+		"#4CAF50:Document Review;\n",
+		"note right\n  Review the submitted document\nend note\n",
+		"|manager|\n#2196F3:Manager Approval;\n",
+		"|editor|\n#ffffff:Publish, notify;\n",
+		"stop\n@enduml\n",
+	}
+
+	for _, fragment := range expected {
+		if !strings.Contains(uml, fragment) {
+			t.Errorf("Expected PlantUML to contain %q, got %s", fragment, uml)
+		}
+	}
+
+	// Test with empty workflow
+	emptyUML := swf.NewWorkflow().VisualizePlantUML()
+	if emptyUML != "@startuml\nstart\nstop\n@enduml\n" {
+		t.Errorf("Unexpected PlantUML for empty workflow: %s", emptyUML)
+	}
+}
@@ -1,5 +1,7 @@
 package swf
 
+import "net/url"
+
 // State represents a workflow state
 type State struct {
 	Name        string
@@ -18,7 +20,7 @@ func NewState(name string) *State {
 	}
 }
 
-// GetActionLink returns the action link for the state
+// GetActionLink returns a simple action link for the state, in the form /responsible/name
 func (s *State) GetActionLink() string {
-	return "/" + s.Responsible + "/" + s.Name
+	return "/" + url.PathEscape(s.Responsible) + "/" + url.PathEscape(s.Name)
 }
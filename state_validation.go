@@ -0,0 +1,174 @@
+package swf
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Kinds of state issues, see ValidateState
+const (
+	StateIssueVersionMismatch    = "version_mismatch"
+	StateIssueUnknownCurrentStep = "unknown_current_step"
+	StateIssueMissingDetails     = "missing_details"
+	StateIssueUnknownDetails     = "unknown_details"
+	StateIssueUnknownHistoryStep = "unknown_history_step"
+)
+
+// StateIssue is a mismatch between a workflow state and the steps of the workflow
+type StateIssue struct {
+	// Kind is one of the StateIssue constants
+	Kind string
+
+	// Step is the name of the step concerned
+	Step string
+
+	// Message describes the issue
+	Message string
+}
+
+// StateError lists the issues of a workflow state which cannot be loaded
+type StateError struct {
+	Issues []StateIssue
+}
+
+// Error returns the issues as a single message
+func (e *StateError) Error() string {
+	messages := lo.Map(e.Issues, func(issue StateIssue, index int) string {
+		return issue.Message
+	})
+	return ErrInvalidState.Error() + ": " + strings.Join(messages, "; ")
+}
+
+// Unwrap allows matching the error with errors.Is(err, ErrInvalidState)
+func (e *StateError) Unwrap() error {
+	return ErrInvalidState
+}
+
+// ValidateState checks a state against the steps of the workflow.
+// A workflow without steps accepts any state.
+//
+// Business logic:
+// 1. Check the versions match, if both the workflow and the state have one
+// 2. Check the current step is one of the steps
+// 3. Check each step has details
+// 4. Check there are no details of unknown steps
+// 5. Check the history only references known steps
+func (w *Workflow) ValidateState(state *WorkflowState) []StateIssue {
+	issues := []StateIssue{}
+	if len(w.steps) == 0 {
+		return issues
+	}
+
+	if w.version != "" && state.Version != "" && w.version != state.Version {
+		issues = append(issues, StateIssue{
+			Kind:    StateIssueVersionMismatch,
+			Message: fmt.Sprintf("state version %s does not match workflow version %s, migrate it first", state.Version, w.version),
+		})
+	}
+
+	if state.CurrentStepName != "" && w.GetStep(state.CurrentStepName) == nil {
+		issues = append(issues, StateIssue{
+			Kind:    StateIssueUnknownCurrentStep,
+			Step:    state.CurrentStepName,
+			Message: fmt.Sprintf("current step %s is not in the workflow", state.CurrentStepName),
+		})
+	}
+
+	for _, step := range w.steps {
+		if state.StepDetails[step.Name] == nil {
+			issues = append(issues, StateIssue{
+				Kind:    StateIssueMissingDetails,
+				Step:    step.Name,
+				Message: fmt.Sprintf("missing details of step %s", step.Name),
+			})
+		}
+	}
+
+	// Map iteration order is random, keep the report stable
+	detailNames := lo.Keys(state.StepDetails)
+	sort.Strings(detailNames)
+
+	for _, name := range detailNames {
+		if w.GetStep(name) == nil {
+			issues = append(issues, StateIssue{
+				Kind:    StateIssueUnknownDetails,
+				Step:    name,
+				Message: fmt.Sprintf("details of step %s which is not in the workflow", name),
+			})
+		}
+	}
+
+	for _, name := range lo.Uniq(state.History) {
+		if w.GetStep(name) == nil {
+			issues = append(issues, StateIssue{
+				Kind:    StateIssueUnknownHistoryStep,
+				Step:    name,
+				Message: fmt.Sprintf("history references step %s which is not in the workflow", name),
+			})
+		}
+	}
+
+	return issues
+}
+
+// FromStringRepair deserializes the workflow state from a string like FromString,
+// repairing the issues which can be repaired, and returning them
+//
+// Business logic:
+// 1. Fill the missing details of steps with empty details
+// 2. Fail with a StateError if issues remain, e.g. an unknown current step
+func (w *Workflow) FromStringRepair(str string) ([]StateIssue, error) {
+	state, err := parseState(str)
+	if err != nil {
+		return nil, err
+	}
+
+	repaired := []StateIssue{}
+	for _, issue := range w.ValidateState(state) {
+		if issue.Kind != StateIssueMissingDetails {
+			continue
+		}
+
+		state.StepDetails[issue.Step] = &StepDetails{Meta: make(map[string]any)}
+		repaired = append(repaired, issue)
+	}
+
+	if issues := w.ValidateState(state); len(issues) > 0 {
+		return repaired, &StateError{Issues: issues}
+	}
+
+	w.state = state
+	return repaired, nil
+}
+
+// parseState deserializes a state, initializing its missing maps and slices
+func parseState(str string) (*WorkflowState, error) {
+	state := &WorkflowState{}
+	if err := json.Unmarshal([]byte(str), state); err != nil {
+		return nil, err
+	}
+
+	if state.History == nil {
+		state.History = make([]string, 0)
+	}
+
+	if state.StepDetails == nil {
+		state.StepDetails = make(map[string]*StepDetails)
+	}
+
+	for _, details := range state.StepDetails {
+		if details != nil && details.Meta == nil {
+			details.Meta = make(map[string]any)
+		}
+	}
+
+	if state.Meta == nil {
+		state.Meta = make(map[string]any)
+	}
+
+	return state, nil
+}
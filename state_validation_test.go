@@ -0,0 +1,117 @@
+package swf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestValidateState(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("review"))
+	wf.AddStep(swf.NewStep("approve"))
+
+	if issues := wf.ValidateState(wf.GetState()); len(issues) != 0 {
+		t.Errorf("Expected no issues for a valid state, got %v", issues)
+	}
+
+	state := &swf.WorkflowState{
+		CurrentStepName: "legal",
+		History:         []string{"review", "legal", "legal"},
+		StepDetails: map[string]*swf.StepDetails{
+			"review": {},
+			"old":    {},
+		},
+	}
+
+	issues := wf.ValidateState(state)
+
+	expected := []swf.StateIssue{
+		{Kind: swf.StateIssueUnknownCurrentStep, Step: "legal"},
+		{Kind: swf.StateIssueMissingDetails, Step: "approve"},
+		{Kind: swf.StateIssueUnknownDetails, Step: "old"},
+		{Kind: swf.StateIssueUnknownHistoryStep, Step: "legal"},
+	}
+
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %v", len(expected), issues)
+	}
+
+	for i, issue := range issues {
+		if issue.Kind != expected[i].Kind || issue.Step != expected[i].Step {
+			t.Errorf("Expected issue %s %s, got %s %s", expected[i].Kind, expected[i].Step, issue.Kind, issue.Step)
+		}
+	}
+
+	// A workflow without steps accepts any state
+	if issues := swf.NewWorkflow().ValidateState(state); len(issues) != 0 {
+		t.Errorf("Expected no issues without steps, got %v", issues)
+	}
+}
+
+func TestFromStringValidation(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("review"))
+	wf.AddStep(swf.NewStep("approve"))
+	before := wf.GetState()
+
+	err := wf.FromString(`{"CurrentStepName":"unknown","StepDetails":{"review":{},"approve":{}}}`)
+
+	stateError := &swf.StateError{}
+	if !errors.As(err, &stateError) || !errors.Is(err, swf.ErrInvalidState) {
+		t.Fatalf("Expected StateError, got %v", err)
+	}
+
+	if len(stateError.Issues) != 1 || stateError.Issues[0].Kind != swf.StateIssueUnknownCurrentStep {
+		t.Errorf("Expected unknown current step issue, got %v", stateError.Issues)
+	}
+
+	if wf.GetState() != before {
+		t.Error("Expected the state to be kept when loading fails")
+	}
+
+	// Missing maps are initialized
+	if err := wf.FromString(`{"CurrentStepName":"review","StepDetails":{"review":{"Meta":null},"approve":{}}}`); err != nil {
+		t.Fatalf("FromString failed: %v", err)
+	}
+
+	if err := wf.SetStepMeta("approve", "key", 1); err != nil {
+		t.Errorf("Expected SetStepMeta to succeed, got %v", err)
+	}
+
+	if wf.GetState().History == nil {
+		t.Error("Expected history to be initialized")
+	}
+}
+
+func TestFromStringRepair(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("review"))
+	wf.AddStep(swf.NewStep("approve"))
+
+	str := `{"CurrentStepName":"review","History":["review"],"StepDetails":{"review":{}}}`
+
+	if err := wf.FromString(str); !errors.Is(err, swf.ErrInvalidState) {
+		t.Fatalf("Expected FromString to fail, got %v", err)
+	}
+
+	repaired, err := wf.FromStringRepair(str)
+	if err != nil {
+		t.Fatalf("FromStringRepair failed: %v", err)
+	}
+
+	if len(repaired) != 1 || repaired[0].Kind != swf.StateIssueMissingDetails || repaired[0].Step != "approve" {
+		t.Errorf("Expected missing details of approve to be repaired, got %v", repaired)
+	}
+
+	if err := wf.AdvanceCurrentStep(); err != nil {
+		t.Errorf("Expected repaired workflow to advance, got %v", err)
+	}
+
+	// Unknown steps cannot be repaired
+	_, err = wf.FromStringRepair(`{"CurrentStepName":"legal","StepDetails":{}}`)
+	if !errors.Is(err, swf.ErrInvalidState) {
+		t.Errorf("Expected ErrInvalidState, got %v", err)
+	}
+}
@@ -1,5 +1,7 @@
 package swf
 
+import "net/url"
+
 // Step represents a single step in a workflow
 type Step struct {
 	// Name is a unique identifier for the step.
@@ -7,9 +9,10 @@ type Step struct {
 	// Must be unique within a workflow and should be in snake_case (e.g., 'document_review').
 	Name string
 
-	// Type defines the step's behavior or category.
-	// Common types include 'normal', 'approval', 'notification', etc.
-	// Defaults to 'normal' if not specified.
+	// Type defines the step's behavior, as registered in a StepTypeRegistry.
+	// Built-in types are 'normal', 'approval', 'notification' and 'automated',
+	// registered by NewStepTypeRegistry but not in DefaultStepTypes.
+	// Defaults to 'normal' if not specified, unregistered types behave as 'normal'.
 	Type string
 
 	// Title is the human-readable display name of the step.
@@ -27,6 +30,32 @@ type Step struct {
 	// Used for routing and notification purposes.
 	// Example: 'admin@example.com' or 'document_approvers'
 	Responsible string
+
+	// Weight is the relative effort of the step, used to calculate the weighted progress.
+	// A step with weight 3 counts three times as much as a step with weight 1.
+	// Defaults to 1 if not specified (zero).
+	Weight float64
+
+	// Form describes the data collected by the step, if any.
+	// The step cannot be completed until its metadata satisfies the form.
+	Form *FormSchema `json:",omitempty"`
+
+	// Guard is a condition on the workflow metadata, as a text/template rendering "true".
+	// The step cannot be completed until the guard is satisfied.
+	// Numbers are float64, as serialized. Example: '{{ge .budget 1000.0}}'
+	Guard string `json:",omitempty"`
+
+	// Approval is the quorum rule of an approval step, voted with Workflow.Vote.
+	// Without a rule, an approval step is approved by setting the "approved_by" metadata.
+	Approval *ApprovalRule `json:",omitempty"`
+}
+
+// GetWeight returns the weight of the step, defaulting to 1
+func (s *Step) GetWeight() float64 {
+	if s.Weight == 0 {
+		return 1
+	}
+	return s.Weight
 }
 
 // NewStep creates a new Step with the given name
@@ -40,10 +69,8 @@ func NewStep(name string) *Step {
 	}
 }
 
-// GetActionLink returns the action link for the step
-// Note: This is a placeholder implementation as the PHP version uses a framework-specific function
+// GetActionLink returns a simple action link for the step, in the form /responsible/name.
+// Use a LinkBuilder for links including the instance ID, a base URL or a signature.
 func (s *Step) GetActionLink() string {
-	// In Go, we would typically use a router or URL builder instead of the PHP action() function
-	// This is a simplified implementation
-	return "/" + s.Responsible + "/" + s.Name
+	return "/" + url.PathEscape(s.Responsible) + "/" + url.PathEscape(s.Name)
 }
@@ -55,3 +55,26 @@ func TestGetActionLink(t *testing.T) {
 		t.Errorf("Expected action link %s, got %s", expectedLink, actionLink)
 	}
 }
+
+func TestStepGetWeight(t *testing.T) {
+	step := swf.NewStep("test_step")
+
+	if step.GetWeight() != 1 {
+		t.Errorf("Expected default weight 1, got %.2f", step.GetWeight())
+	}
+
+	step.Weight = 2.5
+	if step.GetWeight() != 2.5 {
+		t.Errorf("Expected weight 2.5, got %.2f", step.GetWeight())
+	}
+}
+
+func TestGetActionLinkEscaping(t *testing.T) {
+	step := swf.NewStep("manager approval")
+	step.Responsible = "legal/team"
+
+	expectedLink := "/legal%2Fteam/manager%20approval"
+	if step.GetActionLink() != expectedLink {
+		t.Errorf("Expected action link %s, got %s", expectedLink, step.GetActionLink())
+	}
+}
@@ -0,0 +1,254 @@
+package swf
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Built-in step types
+const (
+	StepTypeNormal       = "normal"
+	StepTypeApproval     = "approval"
+	StepTypeNotification = "notification"
+	StepTypeAutomated    = "automated"
+)
+
+// StepType implements the behavior of the steps of a Step.Type
+type StepType interface {
+	// OnEnter is called after a step of this type has become the current step
+	OnEnter(w *Workflow, step *Step) error
+
+	// ValidateComplete checks that a step of this type can be completed,
+	// returning an error wrapping ErrStepIncomplete if not
+	ValidateComplete(w *Workflow, step *Step) error
+
+	// RenderHints returns how the renderers should display a step of this type
+	RenderHints(w *Workflow, step *Step) RenderHints
+}
+
+// RenderHints tells the renderers how to display a step
+type RenderHints struct {
+	// Shape is the DOT node shape, defaults to "box"
+	Shape string
+
+	// Tooltip is added to the tooltip of the step, after its description
+	Tooltip string
+}
+
+// StepTypeRegistry maps the step types to their behavior
+type StepTypeRegistry struct {
+	mu    sync.RWMutex
+	types map[string]StepType
+}
+
+// DefaultStepTypes is the registry used by workflows without their own registry.
+// It is empty, all the types behaving as normal steps, as the types were free-form
+// labels before the registry existed. Opt into the built-in behaviors with
+// SetStepTypes(NewStepTypeRegistry()), or by registering them here.
+var DefaultStepTypes = &StepTypeRegistry{types: map[string]StepType{}}
+
+// NewStepTypeRegistry creates a new registry with the built-in step types
+func NewStepTypeRegistry() *StepTypeRegistry {
+	registry := &StepTypeRegistry{types: map[string]StepType{}}
+
+	registry.Register(StepTypeNormal, NormalStepType{})
+	registry.Register(StepTypeApproval, ApprovalStepType{})
+	registry.Register(StepTypeNotification, &ActionStepType{Shape: "note"})
+	registry.Register(StepTypeAutomated, NewAutomatedStepType())
+
+	return registry
+}
+
+// Register registers the behavior of a step type, replacing any existing one
+func (r *StepTypeRegistry) Register(name string, stepType StepType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.types[name] = stepType
+}
+
+// Get returns the behavior of a step type, or nil if not registered
+func (r *StepTypeRegistry) Get(name string) StepType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.types[name]
+}
+
+// SetStepTypes sets the step type registry of the workflow,
+// instead of DefaultStepTypes
+func (w *Workflow) SetStepTypes(registry *StepTypeRegistry) {
+	w.stepTypes = registry
+}
+
+// GetStepType returns the behavior of a step,
+// unregistered types behaving as normal steps
+func (w *Workflow) GetStepType(step *Step) StepType {
+	registry := w.stepTypes
+	if registry == nil {
+		registry = DefaultStepTypes
+	}
+
+	if stepType := registry.Get(step.Type); stepType != nil {
+		return stepType
+	}
+
+	return NormalStepType{}
+}
+
+// EnterCurrentStep runs the OnEnter hook of the current step, e.g. to start
+// a new instance whose first step is automated. The hook of the first step
+// is not run by AddStep, when the workflow is being defined.
+func (w *Workflow) EnterCurrentStep() error {
+	current := w.GetCurrentStep()
+	if current == nil {
+		return ErrNoCurrentStep
+	}
+
+	return w.enterStep(current)
+}
+
+// enterStep runs the OnEnter hook of a step which has become current
+func (w *Workflow) enterStep(step *Step) error {
+	if err := w.GetStepType(step).OnEnter(w, step); err != nil {
+		return fmt.Errorf("entering step %s: %w", step.Name, err)
+	}
+	return nil
+}
+
+// autoAdvances checks if the type of a step advances the workflow on its own,
+// as soon as the step becomes current
+func autoAdvances(w *Workflow, step *Step) bool {
+	switch w.GetStepType(step).(type) {
+	case *ActionStepType, *AutomatedStepType:
+		return true
+	}
+	return false
+}
+
+// validateComplete checks a step can be completed: its form, its guard and its type
+func (w *Workflow) validateComplete(stepName string) error {
+	if err := w.ValidateStepForm(stepName); err != nil {
+		return err
+	}
+
+	step := w.GetStep(stepName)
+	if step == nil {
+		return nil
+	}
+
+	if err := w.checkGuard(step); err != nil {
+		return err
+	}
+
+	return w.GetStepType(step).ValidateComplete(w, step)
+}
+
+// NormalStepType is a step completed manually, without conditions
+type NormalStepType struct{}
+
+// OnEnter does nothing
+func (NormalStepType) OnEnter(w *Workflow, step *Step) error {
+	return nil
+}
+
+// ValidateComplete always allows the completion
+func (NormalStepType) ValidateComplete(w *Workflow, step *Step) error {
+	return nil
+}
+
+// RenderHints renders the step as a box
+func (NormalStepType) RenderHints(w *Workflow, step *Step) RenderHints {
+	return RenderHints{Shape: "box"}
+}
+
+// ApprovalStepType is a step which can only be completed once approved:
+// by the quorum of votes of its Step.Approval rule, or without a rule,
+// with the "approved_by" metadata set, as done by ApprovalLinks
+type ApprovalStepType struct{}
+
+// OnEnter clears the outcome and the vote progress of a previous activation
+// of the step, the votes being counted per activation
+func (ApprovalStepType) OnEnter(w *Workflow, step *Step) error {
+	w.deleteStepMeta(step.Name, "approved_by", "")
+	w.deleteStepMeta(step.Name, "rejected_by", "")
+
+	if details := w.state.StepDetails[step.Name]; details != nil {
+		details.Progress = nil
+	}
+	return nil
+}
+
+// ValidateComplete checks the step has been approved
+func (ApprovalStepType) ValidateComplete(w *Workflow, step *Step) error {
+	if step.Approval != nil {
+		tally, err := w.GetApprovalTally(step)
+		if err != nil {
+			return err
+		}
+
+		if !tally.Approved {
+			return fmt.Errorf("%w: %s is not approved, %s", ErrStepIncomplete, step.Name, tally)
+		}
+		return nil
+	}
+
+	if approvedBy, _ := w.GetStepMeta(step, "approved_by").(string); approvedBy == "" {
+		return fmt.Errorf("%w: %s is not approved", ErrStepIncomplete, step.Name)
+	}
+	return nil
+}
+
+// RenderHints renders the step as a hexagon, with the vote tally
+// or the approver in the tooltip
+func (ApprovalStepType) RenderHints(w *Workflow, step *Step) RenderHints {
+	hints := RenderHints{Shape: "hexagon"}
+
+	if step.Approval != nil && w.IsStepCurrent(step) {
+		if tally, err := w.GetApprovalTally(step); err == nil {
+			hints.Tooltip = tally.String()
+		}
+		return hints
+	}
+
+	if approvedBy, _ := w.GetStepMeta(step, "approved_by").(string); approvedBy != "" {
+		hints.Tooltip = "Approved by " + approvedBy
+	}
+	return hints
+}
+
+// ActionStepType is a step performing an action when it becomes current,
+// e.g. sending a notification, then completing automatically
+type ActionStepType struct {
+	// Action is run when the step becomes current, if set.
+	// On success the workflow advances to the next step.
+	Action func(w *Workflow, step *Step) error
+
+	// Shape is the DOT node shape of the step
+	Shape string
+}
+
+// OnEnter runs the action, and advances the workflow on success
+func (t *ActionStepType) OnEnter(w *Workflow, step *Step) error {
+	if t.Action != nil {
+		if err := t.Action(w, step); err != nil {
+			return err
+		}
+	}
+
+	if !w.IsStepCurrent(step) {
+		return nil
+	}
+
+	return w.AdvanceCurrentStep()
+}
+
+// ValidateComplete always allows the completion
+func (t *ActionStepType) ValidateComplete(w *Workflow, step *Step) error {
+	return nil
+}
+
+// RenderHints renders the step with the shape of the type
+func (t *ActionStepType) RenderHints(w *Workflow, step *Step) RenderHints {
+	return RenderHints{Shape: t.Shape}
+}
@@ -0,0 +1,225 @@
+package swf_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestApprovalStepType(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(swf.NewStepTypeRegistry())
+
+	approval := swf.NewStep("approval")
+	approval.Type = swf.StepTypeApproval
+	approval.Title = "Approval"
+
+	wf.AddStep(approval)
+	wf.AddStep(swf.NewStep("publish"))
+
+	if err := wf.AdvanceCurrentStep(); !errors.Is(err, swf.ErrStepIncomplete) {
+		t.Errorf("Expected ErrStepIncomplete before approval, got %v", err)
+	}
+
+	if wf.MarkStepAsCompleted("approval") {
+		t.Error("Expected MarkStepAsCompleted to fail before approval")
+	}
+
+	if !strings.Contains(wf.Visualize(), `"approval" [label="Approval" shape=hexagon`) {
+		t.Errorf("Expected hexagon approval node, got %s", wf.Visualize())
+	}
+
+	wf.SetStepMetaBy("approval", "approved_by", "jane", "jane")
+
+	if !strings.Contains(wf.Visualize(), `tooltip="Approved by jane"`) {
+		t.Errorf("Expected approver in tooltip, got %s", wf.Visualize())
+	}
+
+	if err := wf.AdvanceCurrentStep(); err != nil {
+		t.Errorf("Expected AdvanceCurrentStep to succeed after approval, got %v", err)
+	}
+}
+
+func TestActionStepType(t *testing.T) {
+	registry := swf.NewStepTypeRegistry()
+
+	notified := []string{}
+	registry.Register(swf.StepTypeNotification, &swf.ActionStepType{
+		Shape: "note",
+		Action: func(w *swf.Workflow, step *swf.Step) error {
+			notified = append(notified, step.Responsible)
+			return nil
+		},
+	})
+
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(registry)
+
+	notify := swf.NewStep("notify")
+	notify.Type = swf.StepTypeNotification
+	notify.Responsible = "legal"
+
+	wf.AddStep(swf.NewStep("review"))
+	wf.AddStep(notify)
+	wf.AddStep(swf.NewStep("publish"))
+
+	// Entering the notification step notifies and moves on
+	if err := wf.AdvanceCurrentStep(); err != nil {
+		t.Fatalf("AdvanceCurrentStep failed: %v", err)
+	}
+
+	if len(notified) != 1 || notified[0] != "legal" {
+		t.Errorf("Expected legal to be notified, got %v", notified)
+	}
+
+	if wf.GetState().CurrentStepName != "publish" || !wf.IsStepComplete("notify") {
+		t.Errorf("Expected notify completed and publish current, got %s", wf.GetState().CurrentStepName)
+	}
+
+	if !strings.Contains(wf.Visualize(), `"notify" [label="" shape=note`) {
+		t.Errorf("Expected note shape, got %s", wf.Visualize())
+	}
+
+	// Rejecting skips back over the notification step, without notifying again
+	if wf.GetPreviousStep().Name != "review" {
+		t.Errorf("Expected review as the previous step, got %s", wf.GetPreviousStep().Name)
+	}
+
+	if err := wf.RejectCurrentStep(); err != nil {
+		t.Fatalf("RejectCurrentStep failed: %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "review" || len(notified) != 1 {
+		t.Errorf("Expected review current without a new notification, got %s and %v", wf.GetState().CurrentStepName, notified)
+	}
+
+	if wf.IsStepComplete("notify") || wf.IsStepComplete("review") {
+		t.Error("Expected review and notify to be completed again")
+	}
+
+	// Advancing again notifies again
+	if err := wf.AdvanceCurrentStep(); err != nil {
+		t.Fatalf("AdvanceCurrentStep failed: %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "publish" || len(notified) != 2 {
+		t.Errorf("Expected publish current after a new notification, got %s and %v", wf.GetState().CurrentStepName, notified)
+	}
+
+	// Failing actions are reported, the step stays current
+	registry.Register(swf.StepTypeNotification, &swf.ActionStepType{
+		Action: func(w *swf.Workflow, step *swf.Step) error {
+			return errors.New("mail server down")
+		},
+	})
+
+	err := wf.SetCurrentStep("notify")
+	if err == nil || !strings.Contains(err.Error(), "entering step notify: mail server down") {
+		t.Errorf("Expected action error, got %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "notify" {
+		t.Errorf("Expected notify to stay current, got %s", wf.GetState().CurrentStepName)
+	}
+}
+
+type checklistStepType struct {
+	swf.NormalStepType
+}
+
+func (checklistStepType) ValidateComplete(w *swf.Workflow, step *swf.Step) error {
+	if w.GetStepMeta(step, "checked") != true {
+		return swf.ErrStepIncomplete
+	}
+	return nil
+}
+
+func TestCustomStepType(t *testing.T) {
+	registry := swf.NewStepTypeRegistry()
+	registry.Register("checklist", checklistStepType{})
+
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(registry)
+
+	checklist := swf.NewStep("checklist")
+	checklist.Type = "checklist"
+	wf.AddStep(checklist)
+
+	if err := wf.CompleteCurrentStep(); !errors.Is(err, swf.ErrStepIncomplete) {
+		t.Errorf("Expected ErrStepIncomplete, got %v", err)
+	}
+
+	wf.SetStepMeta("checklist", "checked", true)
+	if err := wf.CompleteCurrentStep(); err != nil {
+		t.Errorf("Expected CompleteCurrentStep to succeed, got %v", err)
+	}
+
+	// Unregistered types behave as normal steps
+	unknown := swf.NewStep("unknown")
+	unknown.Type = "unknown"
+	if _, ok := wf.GetStepType(unknown).(swf.NormalStepType); !ok {
+		t.Error("Expected unregistered types to behave as normal steps")
+	}
+}
+
+func TestEnterCurrentStep(t *testing.T) {
+	registry := swf.NewStepTypeRegistry()
+
+	runs := 0
+	registry.Register(swf.StepTypeAutomated, &swf.ActionStepType{
+		Action: func(w *swf.Workflow, step *swf.Step) error {
+			runs++
+			return nil
+		},
+	})
+
+	wf := swf.NewWorkflow()
+	wf.SetStepTypes(registry)
+
+	generate := swf.NewStep("generate")
+	generate.Type = swf.StepTypeAutomated
+	wf.AddStep(generate)
+	wf.AddStep(swf.NewStep("review"))
+
+	// Defining the workflow does not run the first step
+	if runs != 0 {
+		t.Fatalf("Expected no run while defining the workflow, got %d", runs)
+	}
+
+	if err := wf.EnterCurrentStep(); err != nil {
+		t.Fatalf("EnterCurrentStep failed: %v", err)
+	}
+
+	if runs != 1 || wf.GetState().CurrentStepName != "review" {
+		t.Errorf("Expected the automated step to run and complete, got %d runs at %s", runs, wf.GetState().CurrentStepName)
+	}
+}
+
+func TestDefaultStepTypes(t *testing.T) {
+	wf := swf.NewWorkflow()
+
+	approval := swf.NewStep("approval")
+	approval.Type = swf.StepTypeApproval
+
+	notification := swf.NewStep("notification")
+	notification.Type = swf.StepTypeNotification
+
+	wf.AddStep(approval)
+	wf.AddStep(notification)
+	wf.AddStep(swf.NewStep("publish"))
+
+	// The built-in types are opt-in, types are labels by default
+	if !wf.MarkStepAsCompleted("approval") {
+		t.Error("Expected approval step to complete without approval by default")
+	}
+
+	if err := wf.SetCurrentStep("notification"); err != nil {
+		t.Fatalf("SetCurrentStep failed: %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "notification" {
+		t.Errorf("Expected notification step to stay current by default, got %s", wf.GetState().CurrentStepName)
+	}
+}
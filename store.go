@@ -0,0 +1,161 @@
+package swf
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Store persists the states of workflow instances by instance ID.
+//
+// Only the state is stored, the steps are part of the workflow definition
+// in code. To load an instance, create the workflow with its steps and set
+// the stored state with LoadInstance.
+//
+// InstanceUpdate replaces the state without revision checks: callers
+// changing the same instance concurrently must serialize their changes,
+// otherwise the last update wins.
+type Store interface {
+	// InstanceCreate stores the state of a new instance,
+	// returns ErrInstanceExists if the ID is taken
+	InstanceCreate(ctx context.Context, id string, state *WorkflowState) error
+
+	// InstanceFindByID returns the state of an instance,
+	// returns ErrInstanceNotFound if it does not exist
+	InstanceFindByID(ctx context.Context, id string) (*WorkflowState, error)
+
+	// InstanceList returns the IDs of all instances
+	InstanceList(ctx context.Context) ([]string, error)
+
+	// InstanceUpdate replaces the state of an instance,
+	// returns ErrInstanceNotFound if it does not exist
+	InstanceUpdate(ctx context.Context, id string, state *WorkflowState) error
+
+	// InstanceDelete deletes an instance,
+	// returns ErrInstanceNotFound if it does not exist
+	InstanceDelete(ctx context.Context, id string) error
+}
+
+// LoadInstance loads the stored state of an instance into a workflow,
+// after checking it matches the steps of the workflow, as FromString does
+func LoadInstance(ctx context.Context, store Store, id string, w *Workflow) error {
+	state, err := store.InstanceFindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if issues := w.ValidateState(state); len(issues) > 0 {
+		return fmt.Errorf("instance %s: %w", id, &StateError{Issues: issues})
+	}
+
+	w.SetState(state)
+	return nil
+}
+
+// NewInstanceID returns a random 32 characters hex string, as ID of a new instance
+func NewInstanceID() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// MemoryStore is an in-memory Store, for tests and prototypes.
+// States are copied on the way in and out, as a database would.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	instances map[string][]byte
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates a new MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		instances: make(map[string][]byte),
+	}
+}
+
+// InstanceCreate stores the state of a new instance
+func (s *MemoryStore) InstanceCreate(ctx context.Context, id string, state *WorkflowState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.instances[id]; exists {
+		return fmt.Errorf("%w: %s", ErrInstanceExists, id)
+	}
+
+	s.instances[id] = data
+	return nil
+}
+
+// InstanceFindByID returns the state of an instance
+func (s *MemoryStore) InstanceFindByID(ctx context.Context, id string) (*WorkflowState, error) {
+	s.mu.RLock()
+	data, exists := s.instances[id]
+	s.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrInstanceNotFound, id)
+	}
+
+	state := &WorkflowState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// InstanceList returns the IDs of all instances, sorted
+func (s *MemoryStore) InstanceList(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.instances))
+	for id := range s.instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// InstanceUpdate replaces the state of an instance
+func (s *MemoryStore) InstanceUpdate(ctx context.Context, id string, state *WorkflowState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.instances[id]; !exists {
+		return fmt.Errorf("%w: %s", ErrInstanceNotFound, id)
+	}
+
+	s.instances[id] = data
+	return nil
+}
+
+// InstanceDelete deletes an instance
+func (s *MemoryStore) InstanceDelete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.instances[id]; !exists {
+		return fmt.Errorf("%w: %s", ErrInstanceNotFound, id)
+	}
+
+	delete(s.instances, id)
+	return nil
+}
@@ -0,0 +1,131 @@
+package swf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := swf.NewMemoryStore()
+
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("step1"))
+	wf.AddStep(swf.NewStep("step2"))
+
+	err := store.InstanceCreate(ctx, "b", wf.GetState())
+	if err != nil {
+		t.Fatalf("InstanceCreate failed: %v", err)
+	}
+
+	err = store.InstanceCreate(ctx, "a", wf.GetState())
+	if err != nil {
+		t.Fatalf("InstanceCreate failed: %v", err)
+	}
+
+	err = store.InstanceCreate(ctx, "a", wf.GetState())
+	if !errors.Is(err, swf.ErrInstanceExists) {
+		t.Errorf("Expected ErrInstanceExists, got %v", err)
+	}
+
+	ids, err := store.InstanceList(ctx)
+	if err != nil {
+		t.Fatalf("InstanceList failed: %v", err)
+	}
+
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("Expected ids [a b], got %v", ids)
+	}
+
+	// Stored states are copies
+	wf.SetCurrentStep("step2")
+
+	state, err := store.InstanceFindByID(ctx, "a")
+	if err != nil {
+		t.Fatalf("InstanceFindByID failed: %v", err)
+	}
+
+	if state.CurrentStepName != "step1" {
+		t.Errorf("Expected stored current step 'step1', got %s", state.CurrentStepName)
+	}
+
+	err = store.InstanceUpdate(ctx, "a", wf.GetState())
+	if err != nil {
+		t.Fatalf("InstanceUpdate failed: %v", err)
+	}
+
+	state, _ = store.InstanceFindByID(ctx, "a")
+	if state.CurrentStepName != "step2" {
+		t.Errorf("Expected updated current step 'step2', got %s", state.CurrentStepName)
+	}
+
+	// Loading the state into a workflow
+	loaded := swf.NewWorkflow()
+	loaded.AddStep(swf.NewStep("step1"))
+	loaded.AddStep(swf.NewStep("step2"))
+	loaded.SetState(state)
+
+	if !loaded.IsStepComplete("step1") {
+		t.Error("Expected step1 to be complete in the loaded workflow")
+	}
+
+	err = store.InstanceDelete(ctx, "a")
+	if err != nil {
+		t.Fatalf("InstanceDelete failed: %v", err)
+	}
+
+	if _, err := store.InstanceFindByID(ctx, "a"); !errors.Is(err, swf.ErrInstanceNotFound) {
+		t.Errorf("Expected ErrInstanceNotFound, got %v", err)
+	}
+
+	if err := store.InstanceUpdate(ctx, "a", state); !errors.Is(err, swf.ErrInstanceNotFound) {
+		t.Errorf("Expected ErrInstanceNotFound, got %v", err)
+	}
+
+	if err := store.InstanceDelete(ctx, "a"); !errors.Is(err, swf.ErrInstanceNotFound) {
+		t.Errorf("Expected ErrInstanceNotFound, got %v", err)
+	}
+}
+
+func TestLoadInstance(t *testing.T) {
+	ctx := context.Background()
+	store := swf.NewMemoryStore()
+
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("step1"))
+	wf.AddStep(swf.NewStep("step2"))
+	wf.AdvanceCurrentStep()
+
+	id := swf.NewInstanceID()
+	if len(id) != 32 || id == swf.NewInstanceID() {
+		t.Errorf("Expected a random 32 characters ID, got %q", id)
+	}
+
+	store.InstanceCreate(ctx, id, wf.GetState())
+
+	loaded := swf.NewWorkflow()
+	loaded.AddStep(swf.NewStep("step1"))
+	loaded.AddStep(swf.NewStep("step2"))
+
+	if err := swf.LoadInstance(ctx, store, id, loaded); err != nil {
+		t.Fatalf("LoadInstance failed: %v", err)
+	}
+
+	if loaded.GetState().CurrentStepName != "step2" {
+		t.Errorf("Expected loaded instance at step2, got %s", loaded.GetState().CurrentStepName)
+	}
+
+	if err := swf.LoadInstance(ctx, store, "unknown", loaded); !errors.Is(err, swf.ErrInstanceNotFound) {
+		t.Errorf("Expected ErrInstanceNotFound, got %v", err)
+	}
+
+	other := swf.NewWorkflow()
+	other.AddStep(swf.NewStep("other"))
+
+	if err := swf.LoadInstance(ctx, store, id, other); !errors.Is(err, swf.ErrInvalidState) {
+		t.Errorf("Expected ErrInvalidState, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package swf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/samber/lo"
+)
+
+// Template is a JSON workflow definition with text/template placeholders,
+// e.g. for approval workflows differing only by responsible team and thresholds:
+//
+//	{"Steps": [
+//		{"Name": "approval", "Title": "{{.Department}} approval", "Responsible": "{{.Department}}_managers",
+//		 "Form": {"Fields": [{"Name": "amount", "Type": "number", "Max": {{.Threshold}}}]}}
+//	]}
+//
+// The parameters keep their type for the template logic, e.g. {{if .RequireLegal}}
+// or {{if gt .Threshold 1000}}. String parameters are escaped when output,
+// for use inside JSON strings, numbers and booleans are output as JSON values,
+// and {{json .Value}} outputs any value as JSON, e.g. lists.
+type Template struct {
+	tmpl       *template.Template
+	parameters []string
+}
+
+// NewTemplateFromString parses a workflow definition template
+func NewTemplateFromString(str string) (*Template, error) {
+	tmpl, err := template.New("definition").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"json": templateJSON}).
+		Parse(str)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workflow template: %w", err)
+	}
+
+	parameters := []string{}
+	if tmpl.Tree != nil {
+		parameters = templateParameters(tmpl.Tree.Root, parameters, true)
+	}
+	parameters = lo.Uniq(parameters)
+	sort.Strings(parameters)
+
+	return &Template{tmpl: tmpl, parameters: parameters}, nil
+}
+
+// Parameters returns the names of the parameters used by the template, sorted
+func (t *Template) Parameters() []string {
+	return t.parameters
+}
+
+// InstantiateDefinition produces the definition of the template with the given parameters
+//
+// Business logic:
+// 1. Check all the parameters of the template are provided, returning ErrMissingParameters if not
+// 2. Wrap string parameters to be escaped for JSON strings when output
+// 3. Execute the template, and parse the result as a definition
+func (t *Template) InstantiateDefinition(params map[string]any) (*Definition, error) {
+	missing := lo.Filter(t.parameters, func(name string, index int) bool {
+		_, exists := params[name]
+		return !exists
+	})
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingParameters, strings.Join(missing, ", "))
+	}
+
+	values := make(map[string]any, len(params))
+	for name, value := range params {
+		if str, isString := value.(string); isString {
+			values[name] = templateString(str)
+			continue
+		}
+		values[name] = value
+	}
+
+	buf := new(bytes.Buffer)
+	if err := t.tmpl.Execute(buf, values); err != nil {
+		return nil, fmt.Errorf("invalid workflow template: %w", err)
+	}
+
+	definition, err := NewDefinitionFromString(buf.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid instantiated definition: %w", err)
+	}
+
+	return definition, nil
+}
+
+// Instantiate produces a new workflow from the template with the given parameters
+func (t *Template) Instantiate(params map[string]any) (*Workflow, error) {
+	definition, err := t.InstantiateDefinition(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return definition.NewWorkflow()
+}
+
+// templateString is a string parameter of a template,
+// output escaped for use inside JSON strings
+type templateString string
+
+// String returns the JSON-escaped string, without the quotes
+func (s templateString) String() string {
+	encoded, _ := json.Marshal(string(s))
+	return string(encoded[1 : len(encoded)-1])
+}
+
+// templateJSON is the json function of the templates, encoding a value as JSON
+func templateJSON(value any) (string, error) {
+	if str, isString := value.(templateString); isString {
+		value = string(str)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// templateParameters collects the names of the top-level fields used by a template,
+// e.g. Department for {{.Department}} or {{$.Department}}. Inside a range or
+// a with, where the dot changes, only the $ variables are top-level fields.
+func templateParameters(node parse.Node, parameters []string, dotIsRoot bool) []string {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return parameters
+		}
+		for _, child := range n.Nodes {
+			parameters = templateParameters(child, parameters, dotIsRoot)
+		}
+	case *parse.ActionNode:
+		parameters = templateParameters(n.Pipe, parameters, dotIsRoot)
+	case *parse.PipeNode:
+		if n == nil {
+			return parameters
+		}
+		for _, command := range n.Cmds {
+			parameters = templateParameters(command, parameters, dotIsRoot)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			parameters = templateParameters(arg, parameters, dotIsRoot)
+		}
+	case *parse.FieldNode:
+		if dotIsRoot {
+			parameters = append(parameters, n.Ident[0])
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			parameters = append(parameters, n.Ident[1])
+		}
+	case *parse.IfNode:
+		parameters = templateParameters(n.Pipe, parameters, dotIsRoot)
+		parameters = templateParameters(n.List, parameters, dotIsRoot)
+		parameters = templateParameters(n.ElseList, parameters, dotIsRoot)
+	case *parse.RangeNode:
+		parameters = templateParameters(n.Pipe, parameters, dotIsRoot)
+		parameters = templateParameters(n.List, parameters, false)
+		parameters = templateParameters(n.ElseList, parameters, dotIsRoot)
+	case *parse.WithNode:
+		parameters = templateParameters(n.Pipe, parameters, dotIsRoot)
+		parameters = templateParameters(n.List, parameters, false)
+		parameters = templateParameters(n.ElseList, parameters, dotIsRoot)
+	}
+
+	return parameters
+}
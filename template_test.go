@@ -0,0 +1,182 @@
+package swf_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+const approvalTemplate = `{"Version": "{{.Version}}", "Steps": [
+	{"Name": "request", "Title": "{{.Department}} request"},
+	{"Name": "approval", "Title": "{{$.Department}} approval", "Responsible": "{{.Department}}_managers",
+	 "Form": {"Fields": [{"Name": "amount", "Type": "number", "Max": {{.Threshold}}}]}}
+]}`
+
+func TestTemplateInstantiate(t *testing.T) {
+	tmpl, err := swf.NewTemplateFromString(approvalTemplate)
+	if err != nil {
+		t.Fatalf("NewTemplateFromString failed: %v", err)
+	}
+
+	parameters := tmpl.Parameters()
+	if len(parameters) != 3 || parameters[0] != "Department" || parameters[1] != "Threshold" || parameters[2] != "Version" {
+		t.Errorf("Expected parameters Department, Threshold, Version, got %v", parameters)
+	}
+
+	wf, err := tmpl.Instantiate(map[string]any{
+		"Department": `Legal "EU"`,
+		"Threshold":  5000,
+		"Version":    "1",
+	})
+	if err != nil {
+		t.Fatalf("Instantiate failed: %v", err)
+	}
+
+	approval := wf.GetStep("approval")
+	if approval == nil {
+		t.Fatal("Expected approval step")
+	}
+
+	if approval.Title != `Legal "EU" approval` || approval.Responsible != `Legal "EU"_managers` {
+		t.Errorf("Expected department in title and responsible, got %s, %s", approval.Title, approval.Responsible)
+	}
+
+	if approval.Form.Fields[0].Max == nil || *approval.Form.Fields[0].Max != 5000 {
+		t.Errorf("Expected threshold 5000, got %v", approval.Form.Fields[0].Max)
+	}
+
+	if wf.GetVersion() != "1" {
+		t.Errorf("Expected version 1, got %s", wf.GetVersion())
+	}
+
+	// Instances of the template are independent
+	other, err := tmpl.Instantiate(map[string]any{"Department": "Finance", "Threshold": 100, "Version": "1"})
+	if err != nil {
+		t.Fatalf("Instantiate failed: %v", err)
+	}
+
+	if other.GetStep("approval").Responsible != "Finance_managers" {
+		t.Errorf("Expected Finance_managers, got %s", other.GetStep("approval").Responsible)
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	if _, err := swf.NewTemplateFromString(`{"Steps": [{"Name": "{{.Name"}]}`); err == nil {
+		t.Error("Expected error for invalid template")
+	}
+
+	tmpl, _ := swf.NewTemplateFromString(approvalTemplate)
+
+	_, err := tmpl.Instantiate(map[string]any{"Department": "Legal"})
+	if !errors.Is(err, swf.ErrMissingParameters) || err.Error() != "missing template parameters: Threshold, Version" {
+		t.Errorf("Expected missing Threshold and Version, got %v", err)
+	}
+
+	// The result must be a valid definition
+	_, err = tmpl.Instantiate(map[string]any{"Department": "Legal", "Threshold": "high", "Version": "1"})
+	if err == nil {
+		t.Error("Expected error for an invalid instantiated definition")
+	}
+
+	duplicate, _ := swf.NewTemplateFromString(`{"Steps": [{"Name": "{{.A}}"}, {"Name": "{{.B}}"}]}`)
+	if _, err := duplicate.Instantiate(map[string]any{"A": "same", "B": "same"}); err == nil {
+		t.Error("Expected error for duplicate step names")
+	}
+}
+
+func TestTemplateLogic(t *testing.T) {
+	tmpl, err := swf.NewTemplateFromString(`{"Steps": [
+	{"Name": "request"},
+	{{- if .RequireLegal}}
+	{"Name": "legal"},
+	{{- end}}
+	{{- if gt .Threshold 1000}}
+	{"Name": "board", "Approval": {"Approvers": {{json .Board}}}},
+	{{- end}}
+	{"Name": "{{.Department}}_approval"}
+]}`)
+	if err != nil {
+		t.Fatalf("NewTemplateFromString failed: %v", err)
+	}
+
+	parameters := tmpl.Parameters()
+	if len(parameters) != 4 {
+		t.Errorf("Expected parameters Board, Department, RequireLegal, Threshold, got %v", parameters)
+	}
+
+	wf, err := tmpl.Instantiate(map[string]any{
+		"RequireLegal": false,
+		"Threshold":    500,
+		"Board":        []string{"alice"},
+		"Department":   "finance",
+	})
+	if err != nil {
+		t.Fatalf("Instantiate failed: %v", err)
+	}
+
+	if len(wf.GetSteps()) != 2 || wf.GetStep("legal") != nil || wf.GetStep("board") != nil {
+		t.Errorf("Expected the conditional steps to be left out, got %d steps", len(wf.GetSteps()))
+	}
+
+	wf, err = tmpl.Instantiate(map[string]any{
+		"RequireLegal": true,
+		"Threshold":    5000,
+		"Board":        []string{"alice", `bob "the boss"`},
+		"Department":   "finance",
+	})
+	if err != nil {
+		t.Fatalf("Instantiate failed: %v", err)
+	}
+
+	if wf.GetStep("legal") == nil {
+		t.Error("Expected the legal step")
+	}
+
+	board := wf.GetStep("board")
+	if board == nil || len(board.Approval.Approvers) != 2 || board.Approval.Approvers[1] != `bob "the boss"` {
+		t.Errorf("Expected the board step with its approvers, got %+v", board)
+	}
+
+	if wf.GetStep("finance_approval") == nil {
+		t.Error("Expected the finance_approval step")
+	}
+}
+
+func TestTemplateRangeParameters(t *testing.T) {
+	tmpl, err := swf.NewTemplateFromString(`{"Steps": [
+	{{- range $index, $reviewer := .Reviewers}}
+	{"Name": "review_{{$index}}", "Responsible": "{{$reviewer}}", "Title": "{{$.Department}} review"},
+	{{- end}}
+	{{- with .Publisher}}
+	{"Name": "publish", "Responsible": "{{.}}", "Description": "{{$.Channel}}"}
+	{{- end}}
+]}`)
+	if err != nil {
+		t.Fatalf("NewTemplateFromString failed: %v", err)
+	}
+
+	parameters := tmpl.Parameters()
+	if strings.Join(parameters, ",") != "Channel,Department,Publisher,Reviewers" {
+		t.Errorf("Expected parameters Channel, Department, Publisher, Reviewers, got %v", parameters)
+	}
+
+	_, err = tmpl.Instantiate(map[string]any{"Reviewers": []string{"alice"}, "Publisher": "bob", "Channel": "web"})
+	if !errors.Is(err, swf.ErrMissingParameters) || !strings.Contains(err.Error(), "Department") {
+		t.Errorf("Expected ErrMissingParameters for Department, got %v", err)
+	}
+
+	wf, err := tmpl.Instantiate(map[string]any{"Reviewers": []string{"alice", "carol"}, "Publisher": "bob", "Channel": "web", "Department": "Legal"})
+	if err != nil {
+		t.Fatalf("Instantiate failed: %v", err)
+	}
+
+	if review := wf.GetStep("review_1"); review == nil || review.Responsible != "carol" || review.Title != "Legal review" {
+		t.Errorf("Expected the review_1 step of carol, got %+v", review)
+	}
+
+	if publish := wf.GetStep("publish"); publish == nil || publish.Description != "web" {
+		t.Errorf("Expected the publish step, got %+v", publish)
+	}
+}
@@ -0,0 +1,174 @@
+package swf
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+// TimelineBarSpec represents a row (step) in the HTML timeline
+type TimelineBarSpec struct {
+	Name        string
+	Title       string
+	Responsible string
+	Status      string
+	Color       string
+	Started     string
+	Completed   string
+	Duration    string
+	Offset      float64 // left offset of the bar, in percents of the timeline
+	Width       float64 // width of the bar, in percents of the timeline
+	HasBar      bool
+	Current     bool
+}
+
+const timelineTemplateText = `<div class="swf-timeline" style="font-family:Arial,sans-serif;font-size:13px;">
+	<table style="width:100%;border-collapse:collapse;">
+		<thead>
+			<tr>
+				<th style="text-align:left;padding:4px 8px;">Step</th>
+				<th style="text-align:left;padding:4px 8px;">Responsible</th>
+				<th style="text-align:left;padding:4px 8px;width:50%;">Timeline{{if $.Start}} ({{$.Start}} - {{$.End}}){{end}}</th>
+				<th style="text-align:right;padding:4px 8px;">Duration</th>
+			</tr>
+		</thead>
+		<tbody>
+{{- range $bar := $.Bars}}
+			<tr class="swf-timeline-step swf-timeline-{{$bar.Status}}" style="border-top:1px solid #eeeeee;{{if $bar.Current}}font-weight:bold;{{end}}">
+				<td style="padding:4px 8px;" title="{{$bar.Name}}">{{$bar.Title}}{{if $bar.Current}} (current){{end}}</td>
+				<td style="padding:4px 8px;">{{$bar.Responsible}}</td>
+				<td style="padding:4px 8px;">
+					<div style="position:relative;height:16px;background:#f5f5f5;">
+{{- if $bar.HasBar}}
+						<div title="{{$bar.Started}} - {{$bar.Completed}}" style="position:absolute;top:0;height:16px;left:{{printf "%.2f" $bar.Offset}}%;width:{{printf "%.2f" $bar.Width}}%;background:{{$bar.Color}};"></div>
+{{- end}}
+					</div>
+				</td>
+				<td style="padding:4px 8px;text-align:right;">{{$bar.Duration}}</td>
+			</tr>
+{{- end}}
+		</tbody>
+	</table>
+</div>`
+
+var timelineTemplate = template.Must(template.New("timeline").Parse(timelineTemplateText))
+
+// VisualizeTimeline returns a self-contained HTML fragment rendering
+// the workflow instance as a timeline (Gantt chart).
+//
+// Each started step is shown as a bar from its start to its completion time.
+// The current step, which is not yet completed, extends until now.
+// Steps that have not been started are listed without a bar.
+func (w *Workflow) VisualizeTimeline() string {
+	return w.visualizeTimeline(time.Now())
+}
+
+// visualizeTimeline renders the timeline, using now as the end of unfinished steps
+func (w *Workflow) visualizeTimeline(now time.Time) string {
+	type span struct {
+		start time.Time
+		end   time.Time
+		ok    bool
+	}
+
+	spans := make([]span, len(w.steps))
+	var timelineStart, timelineEnd time.Time
+
+	for i, step := range w.steps {
+		details := w.state.StepDetails[step.Name]
+		if details == nil {
+			continue
+		}
+
+		start, ok := details.StartedAt()
+		if !ok {
+			continue
+		}
+
+		end, ok := details.CompletedAt()
+		if !ok || end.Before(start) {
+			end = start
+			if w.IsStepCurrent(step) && now.After(start) {
+				end = now
+			}
+		}
+
+		spans[i] = span{start: start, end: end, ok: true}
+
+		if timelineStart.IsZero() || start.Before(timelineStart) {
+			timelineStart = start
+		}
+
+		if end.After(timelineEnd) {
+			timelineEnd = end
+		}
+	}
+
+	total := timelineEnd.Sub(timelineStart)
+
+	bars := make([]*TimelineBarSpec, 0, len(w.steps))
+	for i, step := range w.steps {
+		status := w.stepStatus(step)
+		bar := &TimelineBarSpec{
+			Name:        step.Name,
+			Title:       step.Title,
+			Responsible: step.Responsible,
+			Status:      status,
+			Color:       stepStatusColor(status),
+			Current:     status == stepStatusCurrent,
+		}
+
+		if bar.Title == "" {
+			bar.Title = step.Name
+		}
+
+		if bar.Color == colorPending {
+			bar.Color = colorEdge
+		}
+
+		if spans[i].ok {
+			bar.HasBar = true
+			bar.Started = spans[i].start.Format(time.RFC3339)
+			bar.Completed = spans[i].end.Format(time.RFC3339)
+			bar.Duration = spans[i].end.Sub(spans[i].start).String()
+
+			if total > 0 {
+				bar.Offset = float64(spans[i].start.Sub(timelineStart)) / float64(total) * 100
+				bar.Width = float64(spans[i].end.Sub(spans[i].start)) / float64(total) * 100
+			}
+
+			// Keep instant steps visible
+			if bar.Width < 1 {
+				bar.Width = 1
+			}
+
+			if bar.Offset+bar.Width > 100 {
+				bar.Offset = 100 - bar.Width
+			}
+		}
+
+		bars = append(bars, bar)
+	}
+
+	data := struct {
+		Start string
+		End   string
+		Bars  []*TimelineBarSpec
+	}{
+		Bars: bars,
+	}
+
+	if !timelineStart.IsZero() {
+		data.Start = timelineStart.Format(time.RFC3339)
+		data.End = timelineEnd.Format(time.RFC3339)
+	}
+
+	buf := new(bytes.Buffer)
+	err := timelineTemplate.Execute(buf, data)
+	if err != nil {
+		return fmt.Sprintf("Error generating timeline: %v", err)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,102 @@
+package swf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVisualizeTimeline(t *testing.T) {
+	wf := NewWorkflow()
+
+	step1 := NewStep("step1")
+	step1.Title = "Document Review"
+	step1.Responsible = "editor"
+
+	step2 := NewStep("step2")
+	step2.Title = "Manager Approval"
+	step2.Responsible = "manager"
+
+	step3 := NewStep("step3")
+	step3.Title = "Final <Sign-off>"
+	step3.Responsible = "legal"
+
+	wf.AddStep(step1)
+	wf.AddStep(step2)
+	wf.AddStep(step3)
+	wf.SetCurrentStep(step2)
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	wf.GetState().StepDetails["step1"].Started = start.Format(time.RFC3339)
+	wf.GetState().StepDetails["step1"].Completed = start.Add(2 * time.Hour).Format(time.RFC3339)
+	wf.GetState().StepDetails["step2"].Started = start.Add(2 * time.Hour).Format(time.RFC3339)
+
+	html := wf.visualizeTimeline(start.Add(4 * time.Hour))
+
+	if !strings.Contains(html, `class="swf-timeline"`) {
+		t.Error("Expected timeline container")
+	}
+
+	// Completed step takes the first half of the timeline
+	if !strings.Contains(html, "left:0.00%;width:50.00%;background:#4CAF50") {
+		t.Errorf("Expected completed step bar, got %s", html)
+	}
+
+	// Current step runs until now
+	if !strings.Contains(html, "left:50.00%;width:50.00%;background:#2196F3") {
+		t.Errorf("Expected current step bar, got %s", html)
+	}
+
+	if !strings.Contains(html, "Manager Approval (current)") {
+		t.Error("Expected current step to be marked")
+	}
+
+	if !strings.Contains(html, "2h0m0s") {
+		t.Error("Expected step duration to be shown")
+	}
+
+	for _, responsible := range []string{"editor", "manager", "legal"} {
+		if !strings.Contains(html, responsible) {
+			t.Errorf("Expected responsible %s to be shown", responsible)
+		}
+	}
+
+	// Titles are escaped
+	if !strings.Contains(html, "Final &lt;Sign-off&gt;") {
+		t.Error("Expected step title to be HTML escaped")
+	}
+
+	// Pending step has no bar
+	if strings.Count(html, "position:absolute") != 2 {
+		t.Errorf("Expected 2 bars, got %d", strings.Count(html, "position:absolute"))
+	}
+
+	// Test with empty workflow
+	emptyHTML := NewWorkflow().VisualizeTimeline()
+	if !strings.Contains(emptyHTML, `class="swf-timeline"`) {
+		t.Error("Expected empty workflow to generate a timeline")
+	}
+}
+
+func TestStepDetailsDuration(t *testing.T) {
+	details := &StepDetails{}
+
+	if _, ok := details.Duration(); ok {
+		t.Error("Expected no duration for a step that has not started")
+	}
+
+	details.Started = "2024-01-01T09:00:00Z"
+	if _, ok := details.Duration(); ok {
+		t.Error("Expected no duration for a step that has not completed")
+	}
+
+	details.Completed = "2024-01-01T09:30:00Z"
+	duration, ok := details.Duration()
+	if !ok {
+		t.Fatal("Expected duration for a completed step")
+	}
+
+	if duration != 30*time.Minute {
+		t.Errorf("Expected duration 30m, got %s", duration)
+	}
+}
@@ -0,0 +1,247 @@
+// Package tracing exports workflow instances as traces, OpenTelemetry-style.
+//
+// Each workflow instance is a trace with a root span, and each step is a child
+// span starting at StepDetails.Started and ending at StepDetails.Completed.
+// As human workflows run for days, spans are not kept in memory while the step
+// is in progress: they are exported once the step completes, and trace and span
+// IDs are derived from the instance ID, so they survive process restarts.
+//
+// Spans are sent to an Exporter, which adapts to the tracing backend
+// (e.g. an OpenTelemetry SDK exporter). InMemoryExporter is provided for tests.
+package tracing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/dracory/swf"
+)
+
+// Span attribute keys
+const (
+	AttributeInstanceID      = "swf.instance.id"
+	AttributeStepName        = "swf.step.name"
+	AttributeStepTitle       = "swf.step.title"
+	AttributeStepType        = "swf.step.type"
+	AttributeStepResponsible = "swf.step.responsible"
+)
+
+// RootSpanName is the name of the span covering the whole workflow instance
+const RootSpanName = "swf.workflow"
+
+// SpanData is a finished span
+type SpanData struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]string
+}
+
+// Exporter sends finished spans to a tracing backend
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+}
+
+// InMemoryExporter keeps the exported spans in memory, for tests
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+var _ Exporter = (*InMemoryExporter)(nil)
+
+// NewInMemoryExporter creates a new InMemoryExporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{spans: []SpanData{}}
+}
+
+// ExportSpans stores the spans
+func (e *InMemoryExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// Spans returns the exported spans
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData{}, e.spans...)
+}
+
+// Reset removes the exported spans
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = []SpanData{}
+}
+
+// Tracer creates spans for workflow instances
+type Tracer struct {
+	exporter Exporter
+
+	// OnError is called when exporting spans from a listener fails.
+	// Defaults to ignoring the error.
+	OnError func(err error)
+}
+
+// NewTracer creates a new Tracer exporting to the given exporter
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Instance returns a listener tracing the workflow instance with the given ID
+//
+//	wf.AddListener(tracer.Instance(instanceID))
+func (t *Tracer) Instance(instanceID string) swf.Listener {
+	return &instanceListener{tracer: t, instanceID: instanceID}
+}
+
+// ExportWorkflow exports the spans of all completed steps of a workflow instance,
+// and the root span if the workflow is finished.
+// Useful to trace instances which ran without a listener.
+func (t *Tracer) ExportWorkflow(ctx context.Context, instanceID string, w *swf.Workflow) error {
+	spans := []SpanData{}
+	for _, step := range w.GetSteps() {
+		if span, ok := StepSpan(instanceID, w, step); ok {
+			spans = append(spans, span)
+		}
+	}
+
+	if span, ok := RootSpan(instanceID, w); ok {
+		spans = append(spans, span)
+	}
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	return t.exporter.ExportSpans(ctx, spans)
+}
+
+// TraceID returns the trace ID of a workflow instance
+func TraceID(instanceID string) string {
+	return hashID(16, "trace", instanceID)
+}
+
+// RootSpan returns the root span of a finished workflow instance,
+// from the start of its first step to the completion of its last step
+func RootSpan(instanceID string, w *swf.Workflow) (SpanData, bool) {
+	steps := w.GetSteps()
+	state := w.GetState()
+	if len(steps) == 0 || len(state.History) == 0 {
+		return SpanData{}, false
+	}
+
+	first := state.StepDetails[state.History[0]]
+	last := state.StepDetails[steps[len(steps)-1].Name]
+	if first == nil || last == nil || !w.IsStepComplete(steps[len(steps)-1]) {
+		return SpanData{}, false
+	}
+
+	start, ok := first.StartedAt()
+	if !ok {
+		return SpanData{}, false
+	}
+
+	end, ok := last.CompletedAt()
+	if !ok || end.Before(start) {
+		return SpanData{}, false
+	}
+
+	return SpanData{
+		TraceID:    TraceID(instanceID),
+		SpanID:     rootSpanID(instanceID),
+		Name:       RootSpanName,
+		Start:      start,
+		End:        end,
+		Attributes: map[string]string{AttributeInstanceID: instanceID},
+	}, true
+}
+
+// StepSpan returns the span of a completed step of a workflow instance
+func StepSpan(instanceID string, w *swf.Workflow, step *swf.Step) (SpanData, bool) {
+	details := w.GetState().StepDetails[step.Name]
+	if details == nil {
+		return SpanData{}, false
+	}
+
+	if _, ok := details.Duration(); !ok {
+		return SpanData{}, false
+	}
+
+	start, _ := details.StartedAt()
+	end, _ := details.CompletedAt()
+
+	return SpanData{
+		TraceID:      TraceID(instanceID),
+		SpanID:       hashID(8, "span", instanceID, step.Name, details.Started),
+		ParentSpanID: rootSpanID(instanceID),
+		Name:         step.Name,
+		Start:        start,
+		End:          end,
+		Attributes: map[string]string{
+			AttributeInstanceID:      instanceID,
+			AttributeStepName:        step.Name,
+			AttributeStepTitle:       step.Title,
+			AttributeStepType:        step.Type,
+			AttributeStepResponsible: step.Responsible,
+		},
+	}, true
+}
+
+// rootSpanID returns the span ID of the root span of a workflow instance
+func rootSpanID(instanceID string) string {
+	return hashID(8, "root", instanceID)
+}
+
+// hashID derives a hex encoded ID of the given size in bytes from the parts
+func hashID(size int, parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:size])
+}
+
+// instanceListener exports the spans of a workflow instance as its steps complete
+type instanceListener struct {
+	tracer     *Tracer
+	instanceID string
+}
+
+// OnStepStarted does nothing, as the span is exported when the step completes
+func (l *instanceListener) OnStepStarted(w *swf.Workflow, step *swf.Step) {}
+
+// OnStepCompleted exports the span of the step, and the root span when the workflow is finished
+func (l *instanceListener) OnStepCompleted(w *swf.Workflow, step *swf.Step) {
+	spans := []SpanData{}
+
+	if span, ok := StepSpan(l.instanceID, w, step); ok {
+		spans = append(spans, span)
+	}
+
+	steps := w.GetSteps()
+	if steps[len(steps)-1] == step {
+		if span, ok := RootSpan(l.instanceID, w); ok {
+			spans = append(spans, span)
+		}
+	}
+
+	if len(spans) == 0 {
+		return
+	}
+
+	err := l.tracer.exporter.ExportSpans(context.Background(), spans)
+	if err != nil && l.tracer.OnError != nil {
+		l.tracer.OnError(err)
+	}
+}
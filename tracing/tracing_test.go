@@ -0,0 +1,149 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dracory/swf"
+	"github.com/dracory/swf/tracing"
+)
+
+func TestTracerInstance(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	tracer := tracing.NewTracer(exporter)
+
+	wf := swf.NewWorkflow()
+
+	reviewStep := swf.NewStep("review")
+	reviewStep.Responsible = "editor"
+
+	approveStep := swf.NewStep("approve")
+	approveStep.Type = "approval"
+	approveStep.Responsible = "manager"
+
+	wf.AddStep(reviewStep)
+	wf.AddStep(approveStep)
+	wf.AddListener(tracer.Instance("doc-42"))
+
+	started := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	wf.GetState().StepDetails["review"].Started = started.Format(time.RFC3339)
+
+	wf.SetCurrentStep("approve")
+
+	spans := exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span after the first step, got %d", len(spans))
+	}
+
+	review := spans[0]
+	if review.Name != "review" || !review.Start.Equal(started) {
+		t.Errorf("Unexpected review span: %+v", review)
+	}
+
+	if review.TraceID != tracing.TraceID("doc-42") || len(review.TraceID) != 32 || len(review.SpanID) != 16 {
+		t.Errorf("Unexpected IDs: trace %s, span %s", review.TraceID, review.SpanID)
+	}
+
+	if review.Attributes[tracing.AttributeStepResponsible] != "editor" ||
+		review.Attributes[tracing.AttributeStepType] != "normal" {
+		t.Errorf("Unexpected attributes: %v", review.Attributes)
+	}
+
+	wf.MarkStepAsCompleted("approve")
+
+	spans = exporter.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans after the workflow finished, got %d", len(spans))
+	}
+
+	approve := spans[1]
+	root := spans[2]
+
+	if approve.Attributes[tracing.AttributeStepType] != "approval" {
+		t.Errorf("Expected approval type attribute, got %v", approve.Attributes)
+	}
+
+	if root.Name != tracing.RootSpanName || root.ParentSpanID != "" {
+		t.Errorf("Unexpected root span: %+v", root)
+	}
+
+	if review.ParentSpanID != root.SpanID || approve.ParentSpanID != root.SpanID {
+		t.Error("Expected step spans to be children of the root span")
+	}
+
+	if !root.Start.Equal(started) || root.End.Before(approve.End) {
+		t.Errorf("Expected root span to cover the workflow, got %s - %s", root.Start, root.End)
+	}
+}
+
+func TestTracerExportWorkflow(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	tracer := tracing.NewTracer(exporter)
+
+	wf := swf.NewWorkflow()
+
+	review := swf.NewStep("review")
+	review.Responsible = "editor"
+
+	approve := swf.NewStep("approve")
+	approve.Type = "approval"
+	approve.Responsible = "manager"
+
+	wf.AddStep(review)
+	wf.AddStep(approve)
+	wf.SetCurrentStep("approve")
+
+	err := tracer.ExportWorkflow(context.Background(), "doc-1", wf)
+	if err != nil {
+		t.Fatalf("ExportWorkflow failed: %v", err)
+	}
+
+	// The approval is still in progress, so only the review is traced
+	spans := exporter.Spans()
+	if len(spans) != 1 || spans[0].Name != "review" {
+		t.Errorf("Expected only the review span, got %+v", spans)
+	}
+
+	// IDs are stable across exports
+	exporter.Reset()
+	tracer.ExportWorkflow(context.Background(), "doc-1", wf)
+	if exporter.Spans()[0].SpanID != spans[0].SpanID {
+		t.Error("Expected span IDs to be stable")
+	}
+}
+
+// failingExporter always fails
+type failingExporter struct{}
+
+func (failingExporter) ExportSpans(ctx context.Context, spans []tracing.SpanData) error {
+	return errors.New("backend unavailable")
+}
+
+func TestTracerOnError(t *testing.T) {
+	tracer := tracing.NewTracer(failingExporter{})
+
+	var reported error
+	tracer.OnError = func(err error) {
+		reported = err
+	}
+
+	wf := swf.NewWorkflow()
+
+	review := swf.NewStep("review")
+	review.Responsible = "editor"
+
+	approve := swf.NewStep("approve")
+	approve.Type = "approval"
+	approve.Responsible = "manager"
+
+	wf.AddStep(review)
+	wf.AddStep(approve)
+	wf.AddListener(tracer.Instance("doc-1"))
+	wf.SetCurrentStep("approve")
+
+	if reported == nil {
+		t.Error("Expected export error to be reported")
+	}
+}
@@ -0,0 +1,134 @@
+package swf
+
+import (
+	"time"
+
+	"github.com/dracory/arr"
+	"github.com/samber/lo"
+)
+
+// GetNextStep returns the step after the current step,
+// or nil if the current step is the last one
+func (w *Workflow) GetNextStep() *Step {
+	index := w.currentStepIndex()
+	if index == -1 || index+1 >= len(w.steps) {
+		return nil
+	}
+
+	return w.steps[index+1]
+}
+
+// GetPreviousStep returns the step before the current step, which a rejection
+// sends the workflow back to, or nil if the current step is the first one.
+// Steps advancing on their own, e.g. notification steps, are skipped, as
+// they would advance back to the current step.
+func (w *Workflow) GetPreviousStep() *Step {
+	for index := w.currentStepIndex() - 1; index >= 0; index-- {
+		if !autoAdvances(w, w.steps[index]) {
+			return w.steps[index]
+		}
+	}
+
+	return nil
+}
+
+// IsFinished checks if the workflow is finished, i.e. its last step is completed
+func (w *Workflow) IsFinished() bool {
+	if len(w.steps) == 0 {
+		return false
+	}
+
+	return w.IsStepComplete(w.steps[len(w.steps)-1])
+}
+
+// CompleteCurrentStep marks the current step as completed,
+// without moving to the next step
+func (w *Workflow) CompleteCurrentStep() error {
+	current := w.GetCurrentStep()
+	if current == nil {
+		return ErrNoCurrentStep
+	}
+
+	return w.CompleteStep(current)
+}
+
+// AdvanceCurrentStep completes the current step and moves to the next step
+//
+// Business logic:
+// 1. Check if there is a current step
+// 2. If the workflow is finished, return ErrWorkflowFinished
+// 3. If the current step is the last one, complete it, finishing the workflow
+// 4. Otherwise check the current step can be completed: its form and its type
+// 5. Make the next step current (completing the current step)
+func (w *Workflow) AdvanceCurrentStep() error {
+	current := w.GetCurrentStep()
+	if current == nil {
+		return ErrNoCurrentStep
+	}
+
+	if w.IsFinished() {
+		return ErrWorkflowFinished
+	}
+
+	next := w.GetNextStep()
+	if next == nil {
+		return w.CompleteStep(current)
+	}
+
+	if err := w.validateComplete(current.Name); err != nil {
+		return err
+	}
+
+	return w.SetCurrentStep(next)
+}
+
+// RejectCurrentStep rejects the current step, sending the workflow back
+// to the previous step, which has to be completed again. Steps advancing on
+// their own in between are skipped, and run again when advancing.
+//
+// Business logic:
+// 1. Check if there is a current and a previous step, skipping the steps advancing on their own
+// 2. Clear the completion of the steps from the previous to the current step
+// 3. Make the previous step current, recording it in the history
+// 4. Run the OnEnter hook of the previous step type
+func (w *Workflow) RejectCurrentStep() error {
+	current := w.GetCurrentStep()
+	if current == nil {
+		return ErrNoCurrentStep
+	}
+
+	previous := w.GetPreviousStep()
+	if previous == nil {
+		return ErrNoPreviousStep
+	}
+
+	_, previousDetails, err := w.stepDetails(previous)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range w.steps[lo.IndexOf(w.steps, previous) : w.currentStepIndex()+1] {
+		_, details, err := w.stepDetails(step)
+		if err != nil {
+			return err
+		}
+		details.Completed = ""
+	}
+
+	w.state.CurrentStepName = previous.Name
+	w.state.History = append(w.state.History, previous.Name)
+	previousDetails.Started = time.Now().Format(time.RFC3339)
+
+	w.notifyStepStarted(previous.Name)
+
+	return w.enterStep(previous)
+}
+
+// currentStepIndex returns the position of the current step, or -1
+func (w *Workflow) currentStepIndex() int {
+	stepNames := lo.Map(w.steps, func(item *Step, index int) string {
+		return item.Name
+	})
+
+	return arr.Index(stepNames, w.state.CurrentStepName)
+}
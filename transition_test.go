@@ -0,0 +1,134 @@
+package swf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dracory/swf"
+)
+
+func TestGetNextAndPreviousStep(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("step1"))
+	wf.AddStep(swf.NewStep("step2"))
+	wf.AddStep(swf.NewStep("step3"))
+
+	if wf.GetPreviousStep() != nil {
+		t.Error("Expected no previous step for the first step")
+	}
+
+	if wf.GetNextStep() == nil || wf.GetNextStep().Name != "step2" {
+		t.Error("Expected next step 'step2'")
+	}
+
+	wf.SetCurrentStep("step3")
+
+	if wf.GetNextStep() != nil {
+		t.Error("Expected no next step for the last step")
+	}
+
+	if wf.GetPreviousStep() == nil || wf.GetPreviousStep().Name != "step2" {
+		t.Error("Expected previous step 'step2'")
+	}
+
+	if swf.NewWorkflow().GetNextStep() != nil {
+		t.Error("Expected no next step for an empty workflow")
+	}
+}
+
+func TestAdvanceCurrentStep(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("step1"))
+	wf.AddStep(swf.NewStep("step2"))
+	wf.AddStep(swf.NewStep("step3"))
+
+	for _, expected := range []string{"step2", "step3"} {
+		if err := wf.AdvanceCurrentStep(); err != nil {
+			t.Fatalf("AdvanceCurrentStep failed: %v", err)
+		}
+
+		if wf.GetState().CurrentStepName != expected {
+			t.Errorf("Expected current step %s, got %s", expected, wf.GetState().CurrentStepName)
+		}
+	}
+
+	if wf.IsFinished() {
+		t.Error("Expected workflow not to be finished before the last step is completed")
+	}
+
+	// Advancing the last step finishes the workflow
+	if err := wf.AdvanceCurrentStep(); err != nil {
+		t.Fatalf("AdvanceCurrentStep failed: %v", err)
+	}
+
+	if !wf.IsFinished() {
+		t.Error("Expected workflow to be finished")
+	}
+
+	if wf.GetProgress().Completed != 3 {
+		t.Errorf("Expected 3 completed steps, got %d", wf.GetProgress().Completed)
+	}
+
+	if err := wf.AdvanceCurrentStep(); !errors.Is(err, swf.ErrWorkflowFinished) {
+		t.Errorf("Expected ErrWorkflowFinished, got %v", err)
+	}
+
+	if err := swf.NewWorkflow().AdvanceCurrentStep(); !errors.Is(err, swf.ErrNoCurrentStep) {
+		t.Errorf("Expected ErrNoCurrentStep, got %v", err)
+	}
+}
+
+func TestCompleteCurrentStep(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("step1"))
+	wf.AddStep(swf.NewStep("step2"))
+	wf.AddStep(swf.NewStep("step3"))
+
+	if err := wf.CompleteCurrentStep(); err != nil {
+		t.Fatalf("CompleteCurrentStep failed: %v", err)
+	}
+
+	if !wf.IsStepComplete("step1") || !wf.IsStepCurrent("step1") {
+		t.Error("Expected step1 to be completed and still current")
+	}
+
+	if err := swf.NewWorkflow().CompleteCurrentStep(); !errors.Is(err, swf.ErrNoCurrentStep) {
+		t.Errorf("Expected ErrNoCurrentStep, got %v", err)
+	}
+}
+
+func TestRejectCurrentStep(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("step1"))
+	wf.AddStep(swf.NewStep("step2"))
+	wf.AddStep(swf.NewStep("step3"))
+
+	if err := wf.RejectCurrentStep(); !errors.Is(err, swf.ErrNoPreviousStep) {
+		t.Errorf("Expected ErrNoPreviousStep, got %v", err)
+	}
+
+	wf.AdvanceCurrentStep()
+	wf.AdvanceCurrentStep()
+	wf.CompleteCurrentStep()
+
+	if err := wf.RejectCurrentStep(); err != nil {
+		t.Fatalf("RejectCurrentStep failed: %v", err)
+	}
+
+	if wf.GetState().CurrentStepName != "step2" {
+		t.Errorf("Expected current step 'step2', got %s", wf.GetState().CurrentStepName)
+	}
+
+	if wf.IsStepComplete("step2") || wf.IsStepComplete("step3") {
+		t.Error("Expected rejected steps not to be complete")
+	}
+
+	if !wf.IsStepComplete("step1") {
+		t.Error("Expected step1 to stay complete")
+	}
+
+	history := wf.GetState().History
+	if len(history) != 4 || history[3] != "step2" {
+		t.Errorf("Expected rejection in history, got %v", history)
+	}
+}
@@ -0,0 +1,90 @@
+package swf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TypedWorkflow is a workflow carrying a strongly typed payload for the whole
+// instance, e.g. the document being reviewed. The payload is stored as JSON in
+// the workflow state, so it survives ToString/FromString and Stores.
+//
+//	type Invoice struct {
+//		Number string
+//		Amount int
+//	}
+//
+//	wf := swf.NewTypedWorkflow[Invoice]()
+//	wf.SetData(Invoice{Number: "INV-1", Amount: 120})
+//	invoice, err := wf.GetData()
+type TypedWorkflow[T any] struct {
+	*Workflow
+}
+
+// NewTypedWorkflow creates a new workflow with a typed payload
+func NewTypedWorkflow[T any]() *TypedWorkflow[T] {
+	return &TypedWorkflow[T]{Workflow: NewWorkflow()}
+}
+
+// Typed wraps an existing workflow to access its payload as type T
+func Typed[T any](w *Workflow) *TypedWorkflow[T] {
+	return &TypedWorkflow[T]{Workflow: w}
+}
+
+// GetData returns the payload of the workflow, or the zero value if not set
+func (w *TypedWorkflow[T]) GetData() (T, error) {
+	var data T
+	if len(w.state.Data) == 0 {
+		return data, nil
+	}
+
+	if err := json.Unmarshal(w.state.Data, &data); err != nil {
+		return data, fmt.Errorf("invalid workflow data: %w", err)
+	}
+
+	return data, nil
+}
+
+// SetData sets the payload of the workflow
+func (w *TypedWorkflow[T]) SetData(data T) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	w.state.Data = encoded
+	return nil
+}
+
+// GetStepMetaAs returns step metadata converted to type T
+//
+// Business logic:
+// 1. Return ErrStepNotFound if the step is unknown, or ErrMetaNotFound if the key is not set
+// 2. Return the value if it already has type T
+// 3. Otherwise convert the value through JSON, e.g. float64 to int after FromString,
+// or a map to a struct
+func GetStepMetaAs[T any](w *Workflow, step any, key string) (T, error) {
+	var result T
+
+	value, err := w.FindStepMeta(step, key)
+	if err != nil {
+		return result, err
+	}
+
+	stepName, _ := stepName(step)
+
+	if typed, ok := value.(T); ok {
+		return typed, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return result, fmt.Errorf("step meta %s.%s: %w", stepName, key, err)
+	}
+
+	if err := json.Unmarshal(encoded, &result); err != nil {
+		return result, fmt.Errorf("step meta %s.%s has type %T: %w", stepName, key, value, err)
+	}
+
+	return result, nil
+}
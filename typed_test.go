@@ -0,0 +1,98 @@
+package swf_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dracory/swf"
+)
+
+type invoice struct {
+	Number  string
+	Amount  int
+	Items   []string
+	Created time.Time
+}
+
+func TestTypedWorkflow(t *testing.T) {
+	wf := swf.NewTypedWorkflow[invoice]()
+	wf.AddStep(swf.NewStep("review"))
+
+	empty, err := wf.GetData()
+	if err != nil || empty.Number != "" {
+		t.Errorf("Expected zero value, got %v %v", empty, err)
+	}
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := wf.SetData(invoice{Number: "INV-1", Amount: 120, Items: []string{"a"}, Created: created}); err != nil {
+		t.Fatalf("SetData failed: %v", err)
+	}
+
+	str, err := wf.ToString()
+	if err != nil {
+		t.Fatalf("ToString failed: %v", err)
+	}
+
+	restored := swf.Typed[invoice](swf.NewWorkflow())
+	if err := restored.FromString(str); err != nil {
+		t.Fatalf("FromString failed: %v", err)
+	}
+
+	data, err := restored.GetData()
+	if err != nil {
+		t.Fatalf("GetData failed: %v", err)
+	}
+
+	if data.Number != "INV-1" || data.Amount != 120 || len(data.Items) != 1 || !data.Created.Equal(created) {
+		t.Errorf("Expected data to survive serialization, got %+v", data)
+	}
+
+	// Invalid data for the type
+	if _, err := swf.Typed[int](restored.Workflow).GetData(); err == nil {
+		t.Error("Expected error for data of another type")
+	}
+}
+
+func TestGetStepMetaAs(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("review"))
+	wf.SetStepMeta("review", "count", 3)
+	wf.SetStepMeta("review", "invoice", invoice{Number: "INV-1"})
+	wf.SetStepMeta("review", "ratio", 1.5)
+
+	str, _ := wf.ToString()
+	wf.FromString(str)
+
+	// JSON turned the int into float64
+	if _, ok := wf.GetStepMeta("review", "count").(float64); !ok {
+		t.Fatalf("Expected float64 after FromString, got %T", wf.GetStepMeta("review", "count"))
+	}
+
+	count, err := swf.GetStepMetaAs[int](wf, "review", "count")
+	if err != nil || count != 3 {
+		t.Errorf("Expected count 3, got %v %v", count, err)
+	}
+
+	data, err := swf.GetStepMetaAs[invoice](wf, "review", "invoice")
+	if err != nil || data.Number != "INV-1" {
+		t.Errorf("Expected invoice INV-1, got %v %v", data, err)
+	}
+
+	ratio, err := swf.GetStepMetaAs[float64](wf, "review", "ratio")
+	if err != nil || ratio != 1.5 {
+		t.Errorf("Expected ratio 1.5, got %v %v", ratio, err)
+	}
+
+	if _, err := swf.GetStepMetaAs[int](wf, "review", "ratio"); err == nil {
+		t.Error("Expected error converting 1.5 to int")
+	}
+
+	if _, err := swf.GetStepMetaAs[int](wf, "review", "missing"); !errors.Is(err, swf.ErrMetaNotFound) {
+		t.Errorf("Expected ErrMetaNotFound, got %v", err)
+	}
+
+	if _, err := swf.GetStepMetaAs[int](wf, "unknown", "count"); !errors.Is(err, swf.ErrStepNotFound) {
+		t.Errorf("Expected ErrStepNotFound for unknown step, got %v", err)
+	}
+}
@@ -0,0 +1,378 @@
+// Package ui provides a server-rendered HTML admin interface
+// for workflow instances, as an http.Handler.
+//
+// Pages list the instances of a swf.Store, and show the details of an
+// instance: its diagram, steps with status and metadata, history, and
+// buttons to complete or reject the current step.
+//
+//	handler := ui.NewHandler(store, newDocumentWorkflow)
+//	handler.BasePath = "/admin/workflows"
+//	http.Handle("/admin/workflows/", http.StripPrefix("/admin/workflows", handler))
+//
+// The handler has no authentication or CSRF protection of its own,
+// mount it behind the middleware of your admin area.
+//
+// Changes are serialized within a Handler, as in the REST API: the Store has
+// no revision checks, run a single handler or lock the instances in your Store.
+//
+// The templates are embedded, and can be overridden with OverrideTemplates.
+// Templates are "list.html" and "instance.html", sharing "header" and "footer".
+package ui
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/dracory/swf"
+)
+
+//go:embed templates/*.html
+var templatesFS embed.FS
+
+// parseDefaultTemplates parses the embedded templates
+func parseDefaultTemplates() *template.Template {
+	return template.Must(template.ParseFS(templatesFS, "templates/*.html"))
+}
+
+// Handler is the HTML admin interface http.Handler
+type Handler struct {
+	store      swf.Store
+	definition func() *swf.Workflow
+	templates  *template.Template
+	mux        *http.ServeMux
+
+	// mu serializes the changes, which load, modify and save the state
+	mu sync.Mutex
+
+	// BasePath is the path the handler is mounted at, e.g. "/admin/workflows",
+	// used to build links and redirects. Defaults to the root.
+	BasePath string
+
+	// Title is the title of the pages, defaults to "Workflows"
+	Title string
+
+	// SVGRenderer renders a DOT graph to SVG, e.g. by running Graphviz.
+	// If nil, the instance page shows the timeline and the DOT source instead.
+	SVGRenderer func(dot string) ([]byte, error)
+
+	// NewID generates the IDs of created instances
+	NewID func() string
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler creates a new HTML admin interface handler
+func NewHandler(store swf.Store, definition func() *swf.Workflow) *Handler {
+	h := &Handler{
+		store:      store,
+		definition: definition,
+		templates:  parseDefaultTemplates(),
+		mux:        http.NewServeMux(),
+		Title:      "Workflows",
+		NewID:      swf.NewInstanceID,
+	}
+
+	h.mux.HandleFunc("GET /{$}", h.listPage)
+	h.mux.HandleFunc("POST /{$}", h.createInstance)
+	h.mux.HandleFunc("GET /{id}", h.instancePage)
+	h.mux.HandleFunc("POST /{id}/complete", h.transition((*swf.Workflow).AdvanceCurrentStep))
+	h.mux.HandleFunc("POST /{id}/reject", h.transition((*swf.Workflow).RejectCurrentStep))
+
+	return h
+}
+
+// OverrideTemplates parses templates overriding the default ones,
+// e.g. to change the "header" with the layout of your admin area
+func (h *Handler) OverrideTemplates(fsys fs.FS, patterns ...string) error {
+	templates, err := parseDefaultTemplates().ParseFS(fsys, patterns...)
+	if err != nil {
+		return err
+	}
+
+	h.templates = templates
+	return nil
+}
+
+// ServeHTTP dispatches the request to the page
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// StepView is a step, as shown on the instance page
+type StepView struct {
+	Name        string
+	Title       string
+	Description string
+	Responsible string
+	Status      string
+	Started     string
+	Completed   string
+	Error       string
+	Meta        []MetaView
+}
+
+// MetaView is a step metadata entry, as shown on the instance page
+type MetaView struct {
+	Key   string
+	Value any
+}
+
+// InstanceView is an instance, as shown on the pages
+type InstanceView struct {
+	BasePath    string
+	Title       string
+	ID          string
+	CurrentStep *StepView
+	Finished    bool
+	CanReject   bool
+	Progress    *swf.Progress
+	Steps       []StepView
+	History     []string
+	Timeline    template.HTML
+	DOT         string
+	SVG         template.HTML
+
+	// Error is the error loading the instance, listed instead of its details
+	Error string
+}
+
+// ListView is the data of the list page
+type ListView struct {
+	BasePath  string
+	Title     string
+	Instances []InstanceView
+}
+
+func (h *Handler) listPage(w http.ResponseWriter, r *http.Request) {
+	ids, err := h.store.InstanceList(r.Context())
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	view := ListView{
+		BasePath:  h.BasePath,
+		Title:     h.Title,
+		Instances: make([]InstanceView, 0, len(ids)),
+	}
+
+	// An instance failing to load, e.g. on an old definition version,
+	// is listed with its error, keeping the other instances reachable
+	for _, id := range ids {
+		wf, err := h.load(r, id)
+		if err != nil {
+			view.Instances = append(view.Instances, InstanceView{BasePath: h.BasePath, ID: id, Error: err.Error()})
+			continue
+		}
+
+		view.Instances = append(view.Instances, h.instanceView(id, wf))
+	}
+
+	h.render(w, "list.html", view)
+}
+
+func (h *Handler) createInstance(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id := h.NewID()
+
+	wf := h.definition()
+	if err := h.store.InstanceCreate(r.Context(), id, wf.GetState()); err != nil {
+		h.error(w, err)
+		return
+	}
+
+	// Enter the first step, e.g. running an automated first step. A failed
+	// automated step is recorded in the state, and shown on the instance page
+	if wf.GetCurrentStep() != nil {
+		if err := wf.EnterCurrentStep(); err != nil && !errors.Is(err, swf.ErrStepFailed) {
+			h.error(w, err)
+			return
+		}
+
+		if err := h.store.InstanceUpdate(r.Context(), id, wf.GetState()); err != nil {
+			h.error(w, err)
+			return
+		}
+	}
+
+	http.Redirect(w, r, h.BasePath+"/"+url.PathEscape(id), http.StatusSeeOther)
+}
+
+func (h *Handler) instancePage(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	wf, err := h.load(r, id)
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	view := h.instanceView(id, wf)
+	view.Title = h.Title + ": " + id
+	view.Timeline = template.HTML(wf.VisualizeTimeline())
+	view.DOT = wf.Visualize()
+
+	if h.SVGRenderer != nil {
+		svg, err := h.SVGRenderer(view.DOT)
+		if err != nil {
+			h.error(w, err)
+			return
+		}
+		view.SVG = template.HTML(svg)
+	}
+
+	h.render(w, "instance.html", view)
+}
+
+// transition returns an endpoint applying a transition to the instance,
+// redirecting back to the instance page
+func (h *Handler) transition(apply func(*swf.Workflow) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		id := r.PathValue("id")
+
+		wf, err := h.load(r, id)
+		if err != nil {
+			h.error(w, err)
+			return
+		}
+
+		// A failed automated step is recorded in the state,
+		// and shown on the instance page
+		if err := apply(wf); err != nil && !errors.Is(err, swf.ErrStepFailed) {
+			h.error(w, err)
+			return
+		}
+
+		if err := h.store.InstanceUpdate(r.Context(), id, wf.GetState()); err != nil {
+			h.error(w, err)
+			return
+		}
+
+		http.Redirect(w, r, h.BasePath+"/"+url.PathEscape(id), http.StatusSeeOther)
+	}
+}
+
+// load loads an instance into a new workflow
+func (h *Handler) load(r *http.Request, id string) (*swf.Workflow, error) {
+	wf := h.definition()
+	if err := swf.LoadInstance(r.Context(), h.store, id, wf); err != nil {
+		return nil, err
+	}
+
+	return wf, nil
+}
+
+// instanceView returns the view of an instance
+func (h *Handler) instanceView(id string, wf *swf.Workflow) InstanceView {
+	view := InstanceView{
+		BasePath:  h.BasePath,
+		Title:     h.Title,
+		ID:        id,
+		Finished:  wf.IsFinished(),
+		CanReject: wf.GetPreviousStep() != nil,
+		Progress:  wf.GetProgress(),
+		History:   wf.GetState().History,
+	}
+
+	for _, step := range wf.GetSteps() {
+		stepView := h.stepView(wf, step)
+		view.Steps = append(view.Steps, stepView)
+
+		if wf.IsStepCurrent(step) {
+			current := stepView
+			view.CurrentStep = &current
+		}
+	}
+
+	return view
+}
+
+// stepView returns the view of a step
+func (h *Handler) stepView(wf *swf.Workflow, step *swf.Step) StepView {
+	view := StepView{
+		Name:        step.Name,
+		Title:       step.Title,
+		Description: step.Description,
+		Responsible: step.Responsible,
+		Status:      "pending",
+		Meta:        []MetaView{},
+	}
+
+	if view.Title == "" {
+		view.Title = step.Name
+	}
+
+	if wf.IsStepCurrent(step) {
+		view.Status = "current"
+	} else if wf.IsStepComplete(step) {
+		view.Status = "completed"
+	}
+
+	details := wf.GetState().StepDetails[step.Name]
+	if details == nil {
+		return view
+	}
+
+	view.Started = details.Started
+	view.Completed = details.Completed
+	view.Error = details.Error
+
+	keys := make([]string, 0, len(details.Meta))
+	for key := range details.Meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		view.Meta = append(view.Meta, MetaView{Key: key, Value: details.Meta[key]})
+	}
+
+	return view
+}
+
+// render renders a template, writing an error if it fails
+func (h *Handler) render(w http.ResponseWriter, name string, data any) {
+	buf := new(bytes.Buffer)
+	if err := h.templates.ExecuteTemplate(buf, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// error writes an error response, mapped from the swf errors
+func (h *Handler) error(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, swf.ErrInstanceNotFound),
+		errors.Is(err, swf.ErrStepNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, swf.ErrWorkflowFinished),
+		errors.Is(err, swf.ErrNoCurrentStep),
+		errors.Is(err, swf.ErrNoPreviousStep),
+		errors.Is(err, swf.ErrStepIncomplete),
+		errors.Is(err, swf.ErrStepFailed):
+		status = http.StatusConflict
+	case errors.Is(err, swf.ErrNotApprover):
+		status = http.StatusForbidden
+	case errors.Is(err, swf.ErrInvalidState):
+		status = http.StatusUnprocessableEntity
+	}
+
+	http.Error(w, err.Error(), status)
+}
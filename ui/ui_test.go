@@ -0,0 +1,240 @@
+package ui_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dracory/swf"
+	"github.com/dracory/swf/ui"
+)
+
+func definition() *swf.Workflow {
+	wf := swf.NewWorkflow()
+
+	review := swf.NewStep("review")
+	review.Title = "Document Review"
+	review.Responsible = "editor"
+
+	approve := swf.NewStep("approve")
+	approve.Title = "Manager Approval"
+	approve.Responsible = "manager"
+
+	wf.AddStep(review)
+	wf.AddStep(approve)
+	return wf
+}
+
+func newHandler(t *testing.T) (*ui.Handler, *swf.MemoryStore) {
+	t.Helper()
+
+	store := swf.NewMemoryStore()
+	handler := ui.NewHandler(store, definition)
+	handler.BasePath = "/admin"
+
+	wf := definition()
+	wf.SetStepMeta("review", "amount", 120)
+	if err := store.InstanceCreate(t.Context(), "doc-1", wf.GetState()); err != nil {
+		t.Fatalf("InstanceCreate failed: %v", err)
+	}
+
+	return handler, store
+}
+
+func serve(handler http.Handler, method string, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder
+}
+
+func TestListPage(t *testing.T) {
+	handler, _ := newHandler(t)
+
+	response := serve(handler, http.MethodGet, "/")
+	if response.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", response.Code)
+	}
+
+	body := response.Body.String()
+	for _, expected := range []string{`<a href="/admin/doc-1">doc-1</a>`, "Document Review", "editor", "0%"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected list page to contain %q, got %s", expected, body)
+		}
+	}
+
+	// Create an instance
+	response = serve(handler, http.MethodPost, "/")
+	if response.Code != http.StatusSeeOther || !strings.HasPrefix(response.Header().Get("Location"), "/admin/") {
+		t.Errorf("Expected redirect to the new instance, got %d %s", response.Code, response.Header().Get("Location"))
+	}
+}
+
+func TestInstancePage(t *testing.T) {
+	handler, store := newHandler(t)
+
+	response := serve(handler, http.MethodGet, "/doc-1")
+	if response.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", response.Code)
+	}
+
+	body := response.Body.String()
+	expected := []string{
+		"Current step: <strong>Document Review</strong> (editor)",
+		`action="/admin/doc-1/complete"`,
+		`class="swf-timeline"`,
+		"digraph",
+		`<span class="status status-current">current</span>`,
+		"<strong>amount</strong>: 120",
+		"<li>review</li>",
+	}
+
+	for _, fragment := range expected {
+		if !strings.Contains(body, fragment) {
+			t.Errorf("Expected instance page to contain %q", fragment)
+		}
+	}
+
+	// No previous step to reject to
+	if strings.Contains(body, "/reject") {
+		t.Error("Expected no reject button on the first step")
+	}
+
+	response = serve(handler, http.MethodGet, "/unknown")
+	if response.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown instance, got %d", response.Code)
+	}
+
+	// States not matching the definition are rejected
+	invalid := definition().GetState()
+	invalid.History = append(invalid.History, "archived")
+	store.InstanceCreate(t.Context(), "doc-2", invalid)
+
+	response = serve(handler, http.MethodGet, "/doc-2")
+	if response.Code != http.StatusUnprocessableEntity || !strings.Contains(response.Body.String(), "archived") {
+		t.Errorf("Expected status 422 for an invalid state, got %d %s", response.Code, response.Body.String())
+	}
+
+	// SVG diagram
+	handler.SVGRenderer = func(dot string) ([]byte, error) {
+		return []byte(`<svg id="diagram"></svg>`), nil
+	}
+
+	body = serve(handler, http.MethodGet, "/doc-1").Body.String()
+	if !strings.Contains(body, `<svg id="diagram"></svg>`) {
+		t.Error("Expected SVG diagram")
+	}
+
+	handler.SVGRenderer = func(dot string) ([]byte, error) {
+		return nil, errors.New("dot not installed")
+	}
+
+	if serve(handler, http.MethodGet, "/doc-1").Code != http.StatusInternalServerError {
+		t.Error("Expected status 500 for failing SVG renderer")
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	handler, store := newHandler(t)
+
+	response := serve(handler, http.MethodPost, "/doc-1/complete")
+	if response.Code != http.StatusSeeOther || response.Header().Get("Location") != "/admin/doc-1" {
+		t.Fatalf("Expected redirect to the instance, got %d %s", response.Code, response.Header().Get("Location"))
+	}
+
+	state, _ := store.InstanceFindByID(t.Context(), "doc-1")
+	if state.CurrentStepName != "approve" {
+		t.Errorf("Expected instance at approve, got %s", state.CurrentStepName)
+	}
+
+	if !strings.Contains(serve(handler, http.MethodGet, "/doc-1").Body.String(), `action="/admin/doc-1/reject"`) {
+		t.Error("Expected reject button on the second step")
+	}
+
+	serve(handler, http.MethodPost, "/doc-1/reject")
+
+	state, _ = store.InstanceFindByID(t.Context(), "doc-1")
+	if state.CurrentStepName != "review" {
+		t.Errorf("Expected instance back at review, got %s", state.CurrentStepName)
+	}
+
+	if serve(handler, http.MethodPost, "/doc-1/reject").Code != http.StatusConflict {
+		t.Error("Expected status 409 when rejecting the first step")
+	}
+}
+
+func TestListPageInvalidInstance(t *testing.T) {
+	handler, store := newHandler(t)
+
+	// An instance of another definition, e.g. an old version
+	store.InstanceCreate(t.Context(), "doc-2", &swf.WorkflowState{CurrentStepName: "legacy"})
+
+	response := serve(handler, http.MethodGet, "/")
+	if response.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", response.Code)
+	}
+
+	body := response.Body.String()
+	for _, expected := range []string{`<a href="/admin/doc-1">doc-1</a>`, "Document Review", `<a href="/admin/doc-2">doc-2</a>`, `class="error"`, "legacy"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected list page to contain %q, got %s", expected, body)
+		}
+	}
+}
+
+func TestConcurrentTransitions(t *testing.T) {
+	handler, store := newHandler(t)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	transitions := 0
+
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			path := "/doc-1/complete"
+			if i%2 == 1 {
+				path = "/doc-1/reject"
+			}
+
+			if serve(handler, http.MethodPost, path).Code == http.StatusSeeOther {
+				mu.Lock()
+				transitions++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	state, _ := store.InstanceFindByID(t.Context(), "doc-1")
+	if len(state.History) != 1+transitions {
+		t.Errorf("Expected the %d transitions to be kept, got history %v", transitions, state.History)
+	}
+}
+
+func TestOverrideTemplates(t *testing.T) {
+	handler, _ := newHandler(t)
+
+	err := handler.OverrideTemplates(fstest.MapFS{
+		"header.html": {Data: []byte(`{{define "header"}}<html><body class="admin">{{end}}`)},
+	}, "*.html")
+	if err != nil {
+		t.Fatalf("OverrideTemplates failed: %v", err)
+	}
+
+	body := serve(handler, http.MethodGet, "/").Body.String()
+	if !strings.HasPrefix(body, `<html><body class="admin">`) {
+		t.Errorf("Expected overridden header, got %s", body)
+	}
+
+	// Other handlers keep the default templates
+	other := ui.NewHandler(swf.NewMemoryStore(), definition)
+	if strings.Contains(serve(other, http.MethodGet, "/").Body.String(), `class="admin"`) {
+		t.Error("Expected default templates for other handlers")
+	}
+}
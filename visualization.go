@@ -3,7 +3,10 @@ package swf
 import (
 	"bytes"
 	"fmt"
+	"strings"
 	"text/template"
+
+	"github.com/samber/lo"
 )
 
 // DotNodeSpec represents a node in the DOT graph
@@ -25,19 +28,90 @@ type DotEdgeSpec struct {
 	Color        string
 }
 
-const dotTemplateText = `digraph {
+// DotClusterSpec represents a cluster (swimlane) of nodes in the DOT graph
+type DotClusterSpec struct {
+	Name  string
+	Label string
+	Nodes []*DotNodeSpec
+}
+
+// VisualizeOptions configures the DOT graph generated by VisualizeWithOptions
+type VisualizeOptions struct {
+	// Swimlanes groups the steps in clusters by Step.Responsible
+	Swimlanes bool
+
+	// Title is the title of the graph, a text/template executed with
+	// the workflow metadata, e.g. "Invoice {{.invoice_id}}"
+	Title string
+}
+
+const dotTemplateText = `{{define "node"}}"{{.Name}}" [label="{{.DisplayName}}" shape={{.Shape}} style={{.Style}} tooltip="{{.Tooltip}}" fillcolor="{{.FillColor}}" {{if eq .Style "filled"}}fontcolor="white"{{end}}]{{end}}digraph {
 	rankdir = "LR"
-	node [fontname="Arial"]
+{{ if $.Title }}	label="{{$.Title}}"
+	labelloc="t"
+{{ end }}	node [fontname="Arial"]
 	edge [fontname="Arial"]
-{{ range $node := $.Nodes}}	"{{$node.Name}}" [label="{{$node.DisplayName}}" shape={{$node.Shape}} style={{$node.Style}} tooltip="{{$node.Tooltip}}" fillcolor="{{$node.FillColor}}" {{if eq $node.Style "filled"}}fontcolor="white"{{end}}]
+{{ range $node := $.Nodes}}	{{template "node" $node}}
+{{ end }}{{ range $cluster := $.Clusters}}	subgraph "{{$cluster.Name}}" {
+		label="{{$cluster.Label}}"
+		style="rounded,dashed"
+		color="#9E9E9E"
+{{ range $node := $cluster.Nodes}}		{{template "node" $node}}
+{{ end }}	}
 {{ end }}        
 {{ range $edge := $.Edges}}	"{{$edge.FromNodeName}}" -> "{{$edge.ToNodeName}}" [style={{$edge.Style}} tooltip="{{$edge.Tooltip}}" color="{{$edge.Color}}"]
 {{ end }}}`
 
 var dotTemplate = template.Must(template.New("digraph").Parse(dotTemplateText))
 
+// Step statuses, as used by the renderers
+const (
+	stepStatusPending   = "pending"
+	stepStatusCurrent   = "current"
+	stepStatusCompleted = "completed"
+)
+
+// Status colors, shared by all renderers
+const (
+	colorPending   = "#ffffff"
+	colorCurrent   = "#2196F3"
+	colorCompleted = "#4CAF50"
+	colorEdge      = "#9E9E9E"
+)
+
+// stepStatus returns the status of a step for rendering purposes
+func (w *Workflow) stepStatus(step *Step) string {
+	if w.IsStepCurrent(step) {
+		return stepStatusCurrent
+	}
+
+	if w.IsStepComplete(step) {
+		return stepStatusCompleted
+	}
+
+	return stepStatusPending
+}
+
+// stepStatusColor returns the fill color for a step status
+func stepStatusColor(status string) string {
+	switch status {
+	case stepStatusCurrent:
+		return colorCurrent
+	case stepStatusCompleted:
+		return colorCompleted
+	default:
+		return colorPending
+	}
+}
+
 // Visualize returns a DOT graph representation of the workflow
 func (w *Workflow) Visualize() string {
+	return w.VisualizeWithOptions(VisualizeOptions{})
+}
+
+// VisualizeWithOptions returns a DOT graph representation of the workflow,
+// configured by the given options
+func (w *Workflow) VisualizeWithOptions(options VisualizeOptions) string {
 	// Handle empty workflow
 	if len(w.steps) == 0 {
 		return `digraph {
@@ -53,23 +127,27 @@ func (w *Workflow) Visualize() string {
 	// Create nodes
 	for i, step := range w.steps {
 		nodeStyle := "solid"
-		fillColor := "#ffffff"
 
-		// Current step is filled blue
-		if w.IsStepCurrent(step) {
+		// Current step is filled blue, completed steps are filled green
+		status := w.stepStatus(step)
+		if status != stepStatusPending {
 			nodeStyle = "filled"
-			fillColor = "#2196F3"
-		} else if w.IsStepComplete(step) {
-			// Completed steps are filled green
-			nodeStyle = "filled"
-			fillColor = "#4CAF50"
+		}
+		fillColor := stepStatusColor(status)
+
+		// Shape and tooltip from the step type
+		hints := w.GetStepType(step).RenderHints(w, step)
+		shape := lo.Ternary(hints.Shape != "", hints.Shape, "box")
+		tooltip := step.Description
+		if hints.Tooltip != "" {
+			tooltip = strings.TrimPrefix(tooltip+`\n`+hints.Tooltip, `\n`)
 		}
 
 		nodes = append(nodes, &DotNodeSpec{
 			Name:        step.Name,
 			DisplayName: step.Title,
-			Tooltip:     step.Description,
-			Shape:       "box",
+			Tooltip:     tooltip,
+			Shape:       shape,
 			Style:       nodeStyle,
 			FillColor:   fillColor,
 		})
@@ -77,11 +155,11 @@ func (w *Workflow) Visualize() string {
 		// Create edges between steps
 		if i > 0 {
 			edgeStyle := "solid"
-			edgeColor := "#9E9E9E"
+			edgeColor := colorEdge
 
 			// Highlight the path up to the current step
 			if w.IsStepComplete(w.steps[i-1]) {
-				edgeColor = "#4CAF50"
+				edgeColor = colorCompleted
 			}
 
 			edges = append(edges, &DotEdgeSpec{
@@ -94,13 +172,41 @@ func (w *Workflow) Visualize() string {
 		}
 	}
 
+	// Group nodes in swimlanes, in order of first appearance
+	clusters := make([]*DotClusterSpec, 0)
+	if options.Swimlanes {
+		clusterIndexes := map[string]int{}
+		for i, step := range w.steps {
+			index, exists := clusterIndexes[step.Responsible]
+			if !exists {
+				index = len(clusters)
+				clusterIndexes[step.Responsible] = index
+				clusters = append(clusters, &DotClusterSpec{
+					Name:  fmt.Sprintf("cluster_%d", index),
+					Label: dotText(step.Responsible),
+				})
+			}
+			clusters[index].Nodes = append(clusters[index].Nodes, nodes[i])
+		}
+		nodes = nil
+	}
+
+	title, err := w.renderTitle(options.Title)
+	if err != nil {
+		return fmt.Sprintf("Error generating DOT graph: %v", err)
+	}
+
 	buf := new(bytes.Buffer)
-	err := dotTemplate.Execute(buf, struct {
-		Nodes []*DotNodeSpec
-		Edges []*DotEdgeSpec
+	err = dotTemplate.Execute(buf, struct {
+		Title    string
+		Nodes    []*DotNodeSpec
+		Clusters []*DotClusterSpec
+		Edges    []*DotEdgeSpec
 	}{
-		Nodes: nodes,
-		Edges: edges,
+		Title:    dotText(title),
+		Nodes:    nodes,
+		Clusters: clusters,
+		Edges:    edges,
 	})
 
 	if err != nil {
@@ -109,3 +215,29 @@ func (w *Workflow) Visualize() string {
 
 	return buf.String()
 }
+
+// renderTitle executes a title template with the workflow metadata, failing on
+// a missing metadata key instead of rendering "<no value>"
+func (w *Workflow) renderTitle(text string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New("title").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, w.state.Meta); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// dotText escapes text for use in a quoted DOT label
+func dotText(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	return strings.ReplaceAll(text, `"`, `\"`)
+}
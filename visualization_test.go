@@ -68,3 +68,101 @@ func TestVisualize(t *testing.T) {
 		t.Error("Expected empty workflow to generate valid DOT graph")
 	}
 }
+
+func TestVisualizeWithSwimlanes(t *testing.T) {
+	wf := NewWorkflow()
+
+	step1 := NewStep("step1")
+	step1.Title = "Document Review"
+	step1.Responsible = "admin"
+
+	step2 := NewStep("step2")
+	step2.Title = "Manager Approval"
+	step2.Responsible = "managers"
+
+	step3 := NewStep("step3")
+	step3.Title = "Legal Review"
+	step3.Responsible = "legal"
+
+	step4 := NewStep("step4")
+	step4.Title = "Publish"
+	step4.Responsible = "admin"
+
+	wf.AddStep(step1)
+	wf.AddStep(step2)
+	wf.AddStep(step3)
+	wf.AddStep(step4)
+
+	dot := wf.VisualizeWithOptions(VisualizeOptions{Swimlanes: true})
+
+	if strings.Count(dot, "subgraph") != 3 {
+		t.Errorf("Expected 3 swimlanes, got %d", strings.Count(dot, "subgraph"))
+	}
+
+	// Steps of the same responsible share a swimlane
+	adminLane := `subgraph "cluster_0" {
+		label="admin"`
+	if !strings.Contains(dot, adminLane) {
+		t.Errorf("Expected admin swimlane, got %s", dot)
+	}
+
+	adminSection := dot[strings.Index(dot, `"cluster_0"`):strings.Index(dot, `"cluster_1"`)]
+	if !strings.Contains(adminSection, `"step1" [`) || !strings.Contains(adminSection, `"step4" [`) {
+		t.Errorf("Expected step1 and step4 in admin swimlane, got %s", adminSection)
+	}
+
+	if !strings.Contains(dot, `label="managers"`) || !strings.Contains(dot, `label="legal"`) {
+		t.Error("Expected managers and legal swimlanes")
+	}
+
+	// Edges cross swimlanes
+	if !strings.Contains(dot, `"step3" -> "step4"`) {
+		t.Error("Expected edge between swimlanes")
+	}
+
+	// Without the option there are no swimlanes
+	if strings.Contains(wf.Visualize(), "subgraph") {
+		t.Error("Expected no swimlanes by default")
+	}
+
+	// Responsibles are escaped in the swimlane labels
+	step4.Responsible = `ACME\"ops"`
+	dot = wf.VisualizeWithOptions(VisualizeOptions{Swimlanes: true})
+	if !strings.Contains(dot, `label="ACME\\\"ops\""`) {
+		t.Errorf("Expected escaped swimlane label, got %s", dot)
+	}
+}
+
+func TestVisualizeWithTitle(t *testing.T) {
+	wf := NewWorkflow()
+	wf.AddStep(NewStep("step1"))
+	wf.SetMeta("document_id", "DOC-42")
+	wf.SetMeta("requester", `Jane "JD" Doe`)
+
+	dot := wf.VisualizeWithOptions(VisualizeOptions{Title: "Document {{.document_id}} by {{.requester}}"})
+
+	if !strings.Contains(dot, `label="Document DOC-42 by Jane \"JD\" Doe"`) {
+		t.Errorf("Expected title from metadata, got %s", dot)
+	}
+
+	if !strings.Contains(dot, `labelloc="t"`) {
+		t.Errorf("Expected title at the top, got %s", dot)
+	}
+
+	if strings.Contains(wf.Visualize(), "labelloc") {
+		t.Error("Expected no title without the option")
+	}
+
+	if dot := wf.VisualizeWithOptions(VisualizeOptions{Title: "{{.document_id"}); !strings.HasPrefix(dot, "Error generating DOT graph") {
+		t.Errorf("Expected error for invalid title template, got %s", dot)
+	}
+
+	if dot := wf.VisualizeWithOptions(VisualizeOptions{Title: "Document {{.missing}}"}); !strings.HasPrefix(dot, "Error generating DOT graph") {
+		t.Errorf("Expected error for a missing metadata key, got %s", dot)
+	}
+
+	wf.SetMeta("path", `C:\docs\`)
+	if dot := wf.VisualizeWithOptions(VisualizeOptions{Title: "{{.path}}"}); !strings.Contains(dot, `label="C:\\docs\\"`) {
+		t.Errorf("Expected escaped backslashes in the title, got %s", dot)
+	}
+}
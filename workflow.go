@@ -14,30 +14,118 @@ type StepDetails struct {
 	Started   string
 	Completed string
 	Meta      map[string]any
+
+	// Progress is the partial progress within the step, if reported
+	Progress *StepProgress `json:",omitempty"`
+	// Votes are the votes on an approval step, see Workflow.Vote
+	Votes []*Vote `json:",omitempty"`
+
+	// Attempts is the number of runs of the handler of an automated step
+	Attempts int `json:",omitempty"`
+
+	// Error is the error of the last failed run of an automated step,
+	// cleared on success
+	Error string `json:",omitempty"`
+}
+
+// StepProgress represents the partial progress within a step,
+// e.g. a checklist with 3 of 5 items done
+type StepProgress struct {
+	Done  int
+	Total int
+}
+
+// StartedAt returns the time the step was started,
+// and false if the step has not been started
+func (d *StepDetails) StartedAt() (time.Time, bool) {
+	return parseStepTime(d.Started)
+}
+
+// CompletedAt returns the time the step was completed,
+// and false if the step has not been completed
+func (d *StepDetails) CompletedAt() (time.Time, bool) {
+	return parseStepTime(d.Completed)
+}
+
+// Duration returns the time spent on the step,
+// and false if the step has not been both started and completed
+func (d *StepDetails) Duration() (time.Duration, bool) {
+	started, ok := d.StartedAt()
+	if !ok {
+		return 0, false
+	}
+
+	completed, ok := d.CompletedAt()
+	if !ok || completed.Before(started) {
+		return 0, false
+	}
+
+	return completed.Sub(started), true
+}
+
+// parseStepTime parses a RFC3339 step timestamp
+func parseStepTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
 }
 
 // WorkflowState represents the current state of a workflow
 type WorkflowState struct {
+	// Version is the version of the workflow definition the state was created with
+	Version string `json:",omitempty"`
+
 	CurrentStepName string
 	// History is the history of steps that have been completed
 	// and the current step, which has been started
 	History     []string
 	StepDetails map[string]*StepDetails
+
+	// Meta is the instance-wide metadata, e.g. document ID, requester or tenant
+	Meta map[string]any `json:",omitempty"`
+
+	// MetaHistory records the changes of step metadata, oldest first
+	MetaHistory []*MetaChange `json:",omitempty"`
+
+	// Data is the typed payload of the workflow instance, see TypedWorkflow
+	Data json.RawMessage `json:",omitempty"`
 }
 
 // Progress represents workflow progress
+//
+// Total, Completed and Pending are step counts, while Percents
+// is weighted by Step.Weight and includes the partial progress
+// reported within steps. With no weights and no partial progress,
+// Percents equals Completed / Total * 100.
 type Progress struct {
 	Total     int
 	Completed int
 	Current   int
 	Pending   int
 	Percents  float64
+
+	// TotalWeight is the sum of the weights of all steps
+	TotalWeight float64
+
+	// CompletedWeight is the sum of the weights of completed steps,
+	// plus the done fraction of the weights of steps in progress
+	CompletedWeight float64
 }
 
 // Workflow represents a workflow
 type Workflow struct {
-	steps []*Step
-	state *WorkflowState
+	version   string
+	steps     []*Step
+	state     *WorkflowState
+	listeners []Listener
+	stepTypes *StepTypeRegistry
 }
 
 // NewWorkflow creates a new Workflow
@@ -47,6 +135,7 @@ func NewWorkflow() *Workflow {
 		state: &WorkflowState{
 			History:     make([]string, 0),
 			StepDetails: make(map[string]*StepDetails),
+			Meta:        make(map[string]any),
 		},
 	}
 }
@@ -63,6 +152,16 @@ func (w *Workflow) AddStep(step *Step) error {
 		return fmt.Errorf("step already exists: %s", step.Name)
 	}
 
+	if step.Weight < 0 {
+		return fmt.Errorf("step weight must not be negative: %s", step.Name)
+	}
+
+	if step.Form != nil {
+		if err := step.Form.check(); err != nil {
+			return fmt.Errorf("step %s: %w", step.Name, err)
+		}
+	}
+
 	w.steps = append(w.steps, step)
 
 	w.state.StepDetails[step.Name] = &StepDetails{
@@ -73,12 +172,24 @@ func (w *Workflow) AddStep(step *Step) error {
 
 	// if first step becomes current step
 	if w.state.CurrentStepName == "" {
-		w.SetCurrentStep(step.Name)
+		w.setCurrentStep(step.Name)
 	}
 
 	return nil
 }
 
+// GetVersion returns the version of the workflow definition
+func (w *Workflow) GetVersion() string {
+	return w.version
+}
+
+// SetVersion sets the version of the workflow definition,
+// which is stored in the state of the instance
+func (w *Workflow) SetVersion(version string) {
+	w.version = version
+	w.state.Version = version
+}
+
 // GetCurrentStep returns the current step
 func (w *Workflow) GetCurrentStep() *Step {
 	if w.state.CurrentStepName == "" {
@@ -94,24 +205,49 @@ func (w *Workflow) GetCurrentStep() *Step {
 // 1. Check if step exists
 // 2. Mark the current step as completed
 // 3. Set the current step to the new step
+// 4. Run the OnEnter hook of the step type
 func (w *Workflow) SetCurrentStep(step any) error {
 	stepName, err := stepName(step)
 	if err != nil {
 		return err
 	}
 
+	if err := w.setCurrentStep(stepName); err != nil {
+		return err
+	}
+
+	return w.enterStep(w.GetStep(stepName))
+}
+
+// setCurrentStep sets the current step, without running the OnEnter hook
+func (w *Workflow) setCurrentStep(stepName string) error {
 	if w.GetStep(stepName) == nil {
-		return fmt.Errorf("step not found: %s", stepName)
+		return fmt.Errorf("%w: %s", ErrStepNotFound, stepName)
+	}
+
+	_, details, err := w.stepDetails(stepName)
+	if err != nil {
+		return err
 	}
 
 	// Mark the current step as completed
-	if w.state.CurrentStepName != "" && w.state.CurrentStepName != stepName {
-		w.state.StepDetails[w.state.CurrentStepName].Completed = time.Now().Format(time.RFC3339)
+	previousStepName := w.state.CurrentStepName
+	previousCompleted := false
+	previous := w.state.StepDetails[previousStepName]
+	if previous != nil && previousStepName != stepName {
+		previousCompleted = previous.Completed == ""
+		previous.Completed = time.Now().Format(time.RFC3339)
 	}
 
 	w.state.CurrentStepName = stepName
 	w.state.History = append(w.state.History, stepName)
-	w.state.StepDetails[stepName].Started = time.Now().Format(time.RFC3339)
+	details.Started = time.Now().Format(time.RFC3339)
+
+	if previousCompleted {
+		w.notifyStepCompleted(previousStepName)
+	}
+	w.notifyStepStarted(stepName)
+
 	return nil
 }
 
@@ -125,17 +261,24 @@ func (w *Workflow) IsStepCurrent(step any) bool {
 	return w.state.CurrentStepName == stepName
 }
 
-// IsStepComplete checks if a step is completed
+// IsStepComplete checks if a step is completed,
+// returning false for unknown steps (see CheckStepComplete)
+func (w *Workflow) IsStepComplete(step any) bool {
+	complete, _ := w.CheckStepComplete(step)
+	return complete
+}
+
+// CheckStepComplete checks if a step is completed
 //
 // Business logic:
-// 1. Get step name
+// 1. Get step name and details, returning ErrInvalidStepRef or ErrStepNotFound
 // 2. Get step positions
 // 3. If step is before the current step, it's complete
 // 4. If step is explicitly marked as completed, it's complete
-func (w *Workflow) IsStepComplete(step any) bool {
-	stepName, err := stepName(step)
+func (w *Workflow) CheckStepComplete(step any) (bool, error) {
+	stepName, details, err := w.stepDetails(step)
 	if err != nil {
-		return false
+		return false, err
 	}
 
 	// Get step positions
@@ -147,12 +290,12 @@ func (w *Workflow) IsStepComplete(step any) bool {
 	stepPosition := arr.Index(stepNames, stepName)
 
 	// If the step is before the current step, it's complete
-	if stepPosition < currentStepPosition {
-		return true
+	if stepPosition != -1 && stepPosition < currentStepPosition {
+		return true, nil
 	}
 
 	// Check if the step is explicitly marked as completed
-	return w.state.StepDetails[stepName].Completed != ""
+	return details.Completed != "", nil
 }
 
 // stepName returns the name of a step, can be a step name or a step pointer
@@ -160,20 +303,42 @@ func (w *Workflow) IsStepComplete(step any) bool {
 // Business logic:
 // 1. Check if step is a string
 // 2. Check if step is a step pointer
-// 3. Return error if step is not a string or a step pointer
+// 3. Return ErrInvalidStepRef if step is not a string or a non-nil step pointer
 func stepName(step any) (string, error) {
 	var stepName string
 	switch s := step.(type) {
 	case string:
 		stepName = s
 	case *Step:
+		if s == nil {
+			return "", fmt.Errorf("%w: nil step", ErrInvalidStepRef)
+		}
 		stepName = s.Name
 	default:
-		return "", fmt.Errorf("invalid step type: %T", step)
+		return "", fmt.Errorf("%w: %T", ErrInvalidStepRef, step)
 	}
 	return stepName, nil
 }
 
+// stepDetails returns the name and details of a step, can be a step name or a step pointer
+//
+// Business logic:
+// 1. Get step name, returning ErrInvalidStepRef for invalid references
+// 2. Return ErrStepNotFound if the state has no details for the step
+func (w *Workflow) stepDetails(step any) (string, *StepDetails, error) {
+	stepName, err := stepName(step)
+	if err != nil {
+		return "", nil, err
+	}
+
+	details := w.state.StepDetails[stepName]
+	if details == nil {
+		return stepName, nil, fmt.Errorf("%w: %s", ErrStepNotFound, stepName)
+	}
+
+	return stepName, details, nil
+}
+
 // GetProgress returns the workflow progress
 func (w *Workflow) GetProgress() *Progress {
 	total := len(w.steps)
@@ -186,25 +351,91 @@ func (w *Workflow) GetProgress() *Progress {
 
 	currentStepPosition := arr.Index(stepNames, w.state.CurrentStepName)
 
+	totalWeight := 0.0
+	completedWeight := 0.0
+
 	// Count completed steps
 	for i, name := range stepNames {
+		weight := w.steps[i].GetWeight()
+		totalWeight += weight
+
 		if i < currentStepPosition || w.IsStepComplete(name) {
 			completed++
+			completedWeight += weight
+			continue
+		}
+
+		// Partial progress within the step
+		details := w.state.StepDetails[name]
+		if details != nil && details.Progress != nil && details.Progress.Total > 0 {
+			completedWeight += weight * float64(details.Progress.Done) / float64(details.Progress.Total)
 		}
 	}
 
 	pending := total - completed
-	percents := float64(completed) / float64(total) * 100
+	percents := 0.0
+	if totalWeight > 0 {
+		percents = completedWeight / totalWeight * 100
+	}
 
 	return &Progress{
-		Total:     total,
-		Completed: completed,
-		Current:   currentStepPosition,
-		Pending:   pending,
-		Percents:  percents,
+		Total:           total,
+		Completed:       completed,
+		Current:         currentStepPosition,
+		Pending:         pending,
+		Percents:        percents,
+		TotalWeight:     totalWeight,
+		CompletedWeight: completedWeight,
 	}
 }
 
+// SetStepProgress reports the partial progress within a step,
+// e.g. 3 of 5 checklist items done
+//
+// Business logic:
+// 1. Get step name
+// 2. Check if step exists
+// 3. Check that done is between 0 and total
+// 4. Store the progress in the step details
+func (w *Workflow) SetStepProgress(step any, done int, total int) error {
+	stepName, err := stepName(step)
+	if err != nil {
+		return err
+	}
+
+	details, exists := w.state.StepDetails[stepName]
+	if !exists || details == nil || w.GetStep(stepName) == nil {
+		return fmt.Errorf("%w: %s", ErrStepNotFound, stepName)
+	}
+
+	if total <= 0 || done < 0 || done > total {
+		return fmt.Errorf("invalid step progress %d/%d for step: %s", done, total, stepName)
+	}
+
+	details.Progress = &StepProgress{
+		Done:  done,
+		Total: total,
+	}
+
+	return nil
+}
+
+// GetStepProgress returns the partial progress reported within a step,
+// or nil if none was reported
+func (w *Workflow) GetStepProgress(step any) *StepProgress {
+	stepName, err := stepName(step)
+	if err != nil {
+		return nil
+	}
+
+	details, exists := w.state.StepDetails[stepName]
+	if !exists || details == nil {
+		return nil
+	}
+
+	return details.Progress
+}
+
 // GetSteps returns all steps
 func (w *Workflow) GetSteps() []*Step {
 	return w.steps
@@ -224,54 +455,88 @@ func (w *Workflow) GetStep(name string) *Step {
 	return w.steps[stepIndex]
 }
 
-// GetStepMeta returns step metadata
+// GetStepMeta returns step metadata,
+// or nil if the step or the key is not found (see FindStepMeta)
+func (w *Workflow) GetStepMeta(step any, key string) any {
+	meta, _ := w.FindStepMeta(step, key)
+	return meta
+}
+
+// FindStepMeta returns step metadata
 //
 // Business logic:
-// 1. Get step name
-// 2. Get step metadata
-// 3. Return metadata or nil if key not found
-func (w *Workflow) GetStepMeta(step any, key string) any {
-	stepName, err := stepName(step)
+// 1. Get step name and details, returning ErrInvalidStepRef or ErrStepNotFound
+// 2. Return ErrMetaNotFound if the key is not set
+// 3. Return metadata
+func (w *Workflow) FindStepMeta(step any, key string) (any, error) {
+	stepName, details, err := w.stepDetails(step)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	meta, exists := w.state.StepDetails[stepName].Meta[key]
+	meta, exists := details.Meta[key]
 	if !exists {
-		return nil
+		return nil, fmt.Errorf("%w: %s.%s", ErrMetaNotFound, stepName, key)
 	}
-	return meta
+	return meta, nil
 }
 
-// SetStepMeta sets step metadata
-func (w *Workflow) SetStepMeta(step any, key string, value interface{}) {
-	stepName, err := stepName(step)
-	if err != nil {
-		return
+// SetStepMeta sets step metadata, recording the change in the metadata history.
+// Use SetStepMetaBy to record who made the change.
+func (w *Workflow) SetStepMeta(step any, key string, value interface{}) error {
+	return w.SetStepMetaBy(step, key, value, "")
+}
+
+// GetMeta returns workflow metadata, or nil if the key is not set
+func (w *Workflow) GetMeta(key string) any {
+	return w.state.Meta[key]
+}
+
+// SetMeta sets workflow metadata
+func (w *Workflow) SetMeta(key string, value any) {
+	if w.state.Meta == nil {
+		w.state.Meta = make(map[string]any)
 	}
 
-	w.state.StepDetails[stepName].Meta[key] = value
+	w.state.Meta[key] = value
+}
+
+// DeleteMeta deletes workflow metadata
+func (w *Workflow) DeleteMeta(key string) {
+	delete(w.state.Meta, key)
 }
 
-// MarkStepAsCompleted marks a step as completed
+// MarkStepAsCompleted marks a step as completed,
+// returning true if step was marked as completed (see CompleteStep)
+func (w *Workflow) MarkStepAsCompleted(step any) bool {
+	return w.CompleteStep(step) == nil
+}
+
+// CompleteStep marks a step as completed
 //
 // Business logic:
-// 1. Get step name
-// 2. Mark step as completed
-// 3. Return true if step was marked as completed
-func (w *Workflow) MarkStepAsCompleted(step any) bool {
-	stepName, err := stepName(step)
+// 1. Get step name and details, returning ErrInvalidStepRef or ErrStepNotFound
+// 2. Check the step metadata satisfies the step form, if any
+// 3. Check the step type allows the completion (see StepType)
+// 4. Mark step as completed
+func (w *Workflow) CompleteStep(step any) error {
+	stepName, details, err := w.stepDetails(step)
 	if err != nil {
-		return false
+		return err
 	}
 
-	if _, exists := w.state.StepDetails[stepName]; !exists {
-		return false
+	if err := w.validateComplete(stepName); err != nil {
+		return err
 	}
 
-	w.state.StepDetails[stepName].Completed = time.Now().Format(time.RFC3339)
+	alreadyCompleted := details.Completed != ""
+	details.Completed = time.Now().Format(time.RFC3339)
+
+	if !alreadyCompleted {
+		w.notifyStepCompleted(stepName)
+	}
 
-	return true
+	return nil
 }
 
 // GetState returns the current workflow state
@@ -279,6 +544,12 @@ func (w *Workflow) GetState() *WorkflowState {
 	return w.state
 }
 
+// SetState replaces the workflow state, e.g. with a state loaded from a Store.
+// The state is not validated, use ValidateState to check it first.
+func (w *Workflow) SetState(state *WorkflowState) {
+	w.state = state
+}
+
 // ToString serializes the workflow state to a string
 func (w *Workflow) ToString() (string, error) {
 	data, err := json.Marshal(w.state)
@@ -289,12 +560,22 @@ func (w *Workflow) ToString() (string, error) {
 }
 
 // FromString deserializes the workflow state from a string
+//
+// Business logic:
+// 1. Parse the state, initializing missing maps
+// 2. Validate the state against the steps of the workflow (see ValidateState)
+// 3. Fail with a StateError listing the issues, keeping the current state
+// 4. Replace the current state
 func (w *Workflow) FromString(str string) error {
-	state := &WorkflowState{}
-	err := json.Unmarshal([]byte(str), state)
+	state, err := parseState(str)
 	if err != nil {
 		return err
 	}
+
+	if issues := w.ValidateState(state); len(issues) > 0 {
+		return &StateError{Issues: issues}
+	}
+
 	w.state = state
 	return nil
 }
@@ -1,6 +1,7 @@
 package swf_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/dracory/swf"
@@ -568,3 +569,247 @@ func TestToStringAndFromString(t *testing.T) {
 		t.Error("Expected error for invalid JSON, got nil")
 	}
 }
+
+func TestGetProgressWeighted(t *testing.T) {
+	wf := swf.NewWorkflow()
+
+	review := swf.NewStep("legal_review")
+	review.Weight = 6
+
+	notify := swf.NewStep("send_notification")
+	notify.Weight = 1
+
+	archive := swf.NewStep("archive")
+	archive.Weight = 3
+
+	wf.AddStep(review)
+	wf.AddStep(notify)
+	wf.AddStep(archive)
+
+	progress := wf.GetProgress()
+	if progress.TotalWeight != 10 {
+		t.Errorf("Expected total weight 10, got %.2f", progress.TotalWeight)
+	}
+
+	if progress.Percents != 0 {
+		t.Errorf("Expected 0%% progress, got %.2f%%", progress.Percents)
+	}
+
+	// Completing the legal review is 60% of the effort
+	wf.SetCurrentStep(notify)
+	progress = wf.GetProgress()
+
+	if progress.Completed != 1 || progress.Total != 3 || progress.Pending != 2 {
+		t.Errorf("Expected unweighted counts 1/3 with 2 pending, got %d/%d with %d pending",
+			progress.Completed, progress.Total, progress.Pending)
+	}
+
+	if progress.Percents != 60 {
+		t.Errorf("Expected 60%% progress, got %.2f%%", progress.Percents)
+	}
+
+	// Partial progress within the archive step
+	wf.SetCurrentStep(archive)
+	err := wf.SetStepProgress(archive, 2, 3)
+	if err != nil {
+		t.Fatalf("SetStepProgress failed: %v", err)
+	}
+
+	progress = wf.GetProgress()
+	if progress.CompletedWeight != 9 {
+		t.Errorf("Expected completed weight 9, got %.2f", progress.CompletedWeight)
+	}
+
+	if progress.Percents != 90 {
+		t.Errorf("Expected 90%% progress, got %.2f%%", progress.Percents)
+	}
+
+	// Negative weights are rejected
+	invalid := swf.NewStep("invalid")
+	invalid.Weight = -1
+	if err := wf.AddStep(invalid); err == nil {
+		t.Error("Expected error for negative weight")
+	}
+}
+
+func TestSetStepProgress(t *testing.T) {
+	wf := swf.NewWorkflow()
+	step1 := swf.NewStep("step1")
+	step2 := swf.NewStep("step2")
+	wf.AddStep(step1)
+	wf.AddStep(step2)
+
+	if wf.GetStepProgress(step1) != nil {
+		t.Error("Expected no progress by default")
+	}
+
+	err := wf.SetStepProgress(step1, 3, 5)
+	if err != nil {
+		t.Fatalf("SetStepProgress failed: %v", err)
+	}
+
+	stepProgress := wf.GetStepProgress("step1")
+	if stepProgress == nil || stepProgress.Done != 3 || stepProgress.Total != 5 {
+		t.Errorf("Expected progress 3/5, got %v", stepProgress)
+	}
+
+	progress := wf.GetProgress()
+	if progress.Percents != 30 {
+		t.Errorf("Expected 30%% progress, got %.2f%%", progress.Percents)
+	}
+
+	// Partial progress survives serialization
+	state, err := wf.ToString()
+	if err != nil {
+		t.Fatalf("ToString failed: %v", err)
+	}
+
+	restored := swf.NewWorkflow()
+	restored.AddStep(swf.NewStep("step1"))
+	restored.AddStep(swf.NewStep("step2"))
+	if err := restored.FromString(state); err != nil {
+		t.Fatalf("FromString failed: %v", err)
+	}
+
+	if restored.GetProgress().Percents != 30 {
+		t.Errorf("Expected 30%% progress after deserialization, got %.2f%%", restored.GetProgress().Percents)
+	}
+
+	// Invalid progress
+	if err := wf.SetStepProgress(step1, 6, 5); err == nil {
+		t.Error("Expected error for done greater than total")
+	}
+
+	if err := wf.SetStepProgress(step1, 0, 0); err == nil {
+		t.Error("Expected error for zero total")
+	}
+
+	if err := wf.SetStepProgress("unknown", 1, 2); err == nil {
+		t.Error("Expected error for unknown step")
+	}
+}
+
+func TestWorkflowMeta(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("step1"))
+
+	if wf.GetMeta("tenant") != nil {
+		t.Error("Expected nil for missing metadata")
+	}
+
+	wf.SetMeta("tenant", "acme")
+	wf.SetMeta("document_id", 42)
+
+	str, err := wf.ToString()
+	if err != nil {
+		t.Fatalf("ToString failed: %v", err)
+	}
+
+	restored := swf.NewWorkflow()
+	if err := restored.FromString(str); err != nil {
+		t.Fatalf("FromString failed: %v", err)
+	}
+
+	if restored.GetMeta("tenant") != "acme" || restored.GetMeta("document_id") != float64(42) {
+		t.Errorf("Expected metadata to survive serialization, got %v", restored.GetState().Meta)
+	}
+
+	restored.DeleteMeta("tenant")
+	if restored.GetMeta("tenant") != nil {
+		t.Error("Expected metadata to be deleted")
+	}
+
+	// States serialized before workflow metadata existed
+	legacy := swf.NewWorkflow()
+	if err := legacy.FromString(`{"CurrentStepName":"","History":[],"StepDetails":{}}`); err != nil {
+		t.Fatalf("FromString failed: %v", err)
+	}
+
+	legacy.SetMeta("tenant", "acme")
+	if legacy.GetMeta("tenant") != "acme" {
+		t.Error("Expected SetMeta to work on a state without metadata")
+	}
+}
+
+func TestUnknownStepErrors(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("step1"))
+	wf.AddStep(swf.NewStep("step2"))
+
+	// Non-panicking variants
+	if wf.GetStepMeta("unknown", "key") != nil {
+		t.Error("Expected nil meta for unknown step")
+	}
+
+	if wf.IsStepComplete("unknown") {
+		t.Error("Expected unknown step not to be complete")
+	}
+
+	if wf.MarkStepAsCompleted("unknown") {
+		t.Error("Expected MarkStepAsCompleted to fail for unknown step")
+	}
+
+	// Error-returning variants
+	tests := []struct {
+		name     string
+		call     func() error
+		expected error
+	}{
+		{"SetStepMeta unknown", func() error { return wf.SetStepMeta("unknown", "key", 1) }, swf.ErrStepNotFound},
+		{"SetStepMeta invalid", func() error { return wf.SetStepMeta(42, "key", 1) }, swf.ErrInvalidStepRef},
+		{"SetStepMeta nil step", func() error { return wf.SetStepMeta((*swf.Step)(nil), "key", 1) }, swf.ErrInvalidStepRef},
+		{"FindStepMeta unknown", func() error { _, err := wf.FindStepMeta("unknown", "key"); return err }, swf.ErrStepNotFound},
+		{"FindStepMeta missing key", func() error { _, err := wf.FindStepMeta("step1", "key"); return err }, swf.ErrMetaNotFound},
+		{"CheckStepComplete unknown", func() error { _, err := wf.CheckStepComplete("unknown"); return err }, swf.ErrStepNotFound},
+		{"CheckStepComplete invalid", func() error { _, err := wf.CheckStepComplete(3.14); return err }, swf.ErrInvalidStepRef},
+		{"CompleteStep unknown", func() error { return wf.CompleteStep("unknown") }, swf.ErrStepNotFound},
+		{"SetCurrentStep unknown", func() error { return wf.SetCurrentStep("unknown") }, swf.ErrStepNotFound},
+		{"SetCurrentStep invalid", func() error { return wf.SetCurrentStep(nil) }, swf.ErrInvalidStepRef},
+		{"SetStepProgress unknown", func() error { return wf.SetStepProgress("unknown", 1, 2) }, swf.ErrStepNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.call(); !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+
+	if err := wf.SetStepMeta("step1", "key", 1); err != nil {
+		t.Errorf("Expected SetStepMeta to succeed, got %v", err)
+	}
+
+	if meta, err := wf.FindStepMeta("step1", "key"); err != nil || meta != 1 {
+		t.Errorf("Expected meta 1, got %v %v", meta, err)
+	}
+
+	if err := wf.CompleteStep("step1"); err != nil {
+		t.Errorf("Expected CompleteStep to succeed, got %v", err)
+	}
+
+	if complete, err := wf.CheckStepComplete("step1"); err != nil || !complete {
+		t.Errorf("Expected step1 complete, got %v %v", complete, err)
+	}
+}
+
+func TestStateWithMissingDetails(t *testing.T) {
+	wf := swf.NewWorkflow()
+	wf.AddStep(swf.NewStep("step1"))
+	wf.AddStep(swf.NewStep("step2"))
+
+	// A state without the details of the steps must not panic
+	wf.SetState(&swf.WorkflowState{CurrentStepName: "step1"})
+
+	if wf.IsStepComplete("step2") {
+		t.Error("Expected step2 not to be complete")
+	}
+
+	if err := wf.SetCurrentStep("step2"); !errors.Is(err, swf.ErrStepNotFound) {
+		t.Errorf("Expected ErrStepNotFound, got %v", err)
+	}
+
+	if err := wf.SetStepMeta("step1", "key", 1); !errors.Is(err, swf.ErrStepNotFound) {
+		t.Errorf("Expected ErrStepNotFound, got %v", err)
+	}
+}